@@ -0,0 +1,138 @@
+package vite
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AssetsManifestPath is the path the handler serves the assets manifest
+// at, if Config.ServeAssetsManifest is set. See [Manifest.AssetInfos].
+const AssetsManifestPath = "/.well-known/vite-assets.json"
+
+// AssetInfo describes an output file of the Vite build.
+type AssetInfo struct {
+	// URL is the URL of the file, e.g. "/assets/main-4f2e1a.js".
+	URL string `json:"url"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// Integrity is the Subresource Integrity hash of the file, e.g.
+	// "sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC".
+	Integrity string `json:"integrity"`
+}
+
+// AssetInfos returns the size and the integrity hash of all output files
+// referenced by the manifest, with URLs under the given base, e.g. "/".
+// The files are read from fsys, the Vite output directory. It returns an
+// error if a file cannot be read.
+func (m Manifest) AssetInfos(fsys fs.FS, base string) ([]AssetInfo, error) {
+	files := m.Files()
+	infos := make([]AssetInfo, 0, len(files))
+	for _, file := range files {
+		info, err := readAssetInfo(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		info.URL = base + file
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// readAssetInfo returns the size and the integrity hash of a file.
+func readAssetInfo(fsys fs.FS, file string) (AssetInfo, error) {
+	f, err := fsys.Open(file)
+	if err != nil {
+		return AssetInfo{}, fmt.Errorf("vite: open asset: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha512.New384()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return AssetInfo{}, fmt.Errorf("vite: read asset %s: %w", file, err)
+	}
+	return AssetInfo{
+		Size:      size,
+		Integrity: "sha384-" + base64.StdEncoding.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// assetsManifest caches the assets manifest of the current Vite manifest,
+// as the files have to be read to compute it.
+type assetsManifest struct {
+	mu       sync.Mutex
+	manifest *Manifest // the manifest data was computed for
+	data     []byte
+	etag     string
+}
+
+// serveAssetsManifest serves the assets manifest as JSON, i.e. an object
+// with the asset infos of all output files:
+//
+//	{"files": [{"url": "/assets/main-4f2e1a.js", "size": 1234, "integrity": "sha384-..."}]}
+//
+// The response has an ETag that changes with the build, so that polling
+// clients, e.g. deployment dashboards, can send If-None-Match and get a
+// 304 Not Modified until a new version of the frontend is deployed.
+func (h *Handler) serveAssetsManifest(w http.ResponseWriter, r *http.Request) {
+	data, etag, err := h.assetsManifestJSON()
+	if err != nil {
+		h.logger.Warn("Unable to create assets manifest", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	serveJSON(w, r, data, etag)
+}
+
+// serveJSON serves data derived from the manifest as JSON, with the given
+// ETag (see [jsonETag]). Clients have to revalidate the response, and get
+// a 304 Not Modified while the ETag matches If-None-Match.
+func serveJSON(w http.ResponseWriter, r *http.Request, data []byte, etag string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// jsonETag returns the ETag for the JSON data served by serveJSON.
+func jsonETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// assetsManifestJSON returns the assets manifest for the current manifest,
+// and its ETag.
+func (h *Handler) assetsManifestJSON() ([]byte, string, error) {
+	m := h.manifest.Load()
+
+	h.assets.mu.Lock()
+	defer h.assets.mu.Unlock()
+	if h.assets.manifest == m && h.assets.data != nil {
+		return h.assets.data, h.assets.etag, nil
+	}
+
+	infos, err := m.AssetInfos(h.fs, h.base)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := json.Marshal(struct {
+		Files []AssetInfo `json:"files"`
+	}{infos})
+	if err != nil {
+		return nil, "", err
+	}
+	h.assets.manifest = m
+	h.assets.data = data
+	h.assets.etag = jsonETag(data)
+	return data, h.assets.etag, nil
+}
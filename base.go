@@ -0,0 +1,66 @@
+package vite
+
+import (
+	"net/url"
+	"strings"
+)
+
+// normalizeBase returns the public base path in the canonical form with a
+// leading and a trailing slash, e.g. "/app/". Full URLs, e.g.
+// "https://cdn.example.com/app/", are kept, with a trailing slash.
+func normalizeBase(base string) string {
+	if base == "" || base == "/" || base == "./" {
+		return "/"
+	}
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	if strings.HasPrefix(base, "http://") || strings.HasPrefix(base, "https://") || strings.HasPrefix(base, "//") {
+		return base
+	}
+	return "/" + strings.TrimLeft(base, "./")
+}
+
+// basePath returns the path of a normalized base, e.g. "/app/" for
+// "https://cdn.example.com/app/".
+func basePath(base string) string {
+	if strings.HasPrefix(base, "/") && !strings.HasPrefix(base, "//") {
+		return base
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// devServerURL returns the URL the Vite dev server serves the app under,
+// i.e. viteURL with the path of base.
+func devServerURL(viteURL, base string) string {
+	p := basePath(base)
+	if p == "/" {
+		return viteURL
+	}
+	u, err := url.JoinPath(viteURL, p)
+	if err != nil {
+		return viteURL
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+// stripBase returns the path relative to the path of base, e.g. "/about"
+// for "/app/about" and base "/app/". It returns false if p is not under
+// base.
+func stripBase(p, base string) (string, bool) {
+	bp := basePath(base)
+	if bp == "/" {
+		return p, true
+	}
+	if p+"/" == bp {
+		return "/", true
+	}
+	if rest, ok := strings.CutPrefix(p, bp); ok {
+		return "/" + rest, true
+	}
+	return p, false
+}
@@ -0,0 +1,59 @@
+package vite
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BotDetector reports whether a request comes from a crawler, e.g. a search
+// engine or a link preview bot.
+type BotDetector func(r *http.Request) bool
+
+// DefaultBotUserAgents are substrings of the User-Agent headers of common
+// crawlers and link preview bots, in lower case.
+var DefaultBotUserAgents = []string{
+	"googlebot",
+	"google-inspectiontool",
+	"bingbot",
+	"yandexbot",
+	"baiduspider",
+	"duckduckbot",
+	"slurp",
+	"applebot",
+	"facebookexternalhit",
+	"facebot",
+	"twitterbot",
+	"linkedinbot",
+	"slackbot",
+	"discordbot",
+	"telegrambot",
+	"whatsapp",
+	"pinterestbot",
+	"embedly",
+	"redditbot",
+}
+
+// NewBotDetector returns a [BotDetector] that matches requests whose
+// User-Agent contains one of the given substrings, ignoring case. If no
+// user agents are given, it uses [DefaultBotUserAgents].
+func NewBotDetector(userAgents ...string) BotDetector {
+	if len(userAgents) == 0 {
+		userAgents = DefaultBotUserAgents
+	}
+	patterns := make([]string, len(userAgents))
+	for i, ua := range userAgents {
+		patterns[i] = strings.ToLower(ua)
+	}
+	return func(r *http.Request) bool {
+		ua := strings.ToLower(r.UserAgent())
+		if ua == "" {
+			return false
+		}
+		for _, p := range patterns {
+			if strings.Contains(ua, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
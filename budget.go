@@ -0,0 +1,76 @@
+package vite
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// AssetBudget is the size of the files a page loads for its entry points,
+// in bytes. Templates get it as .Budget in production mode, e.g. to render
+// a lighter skeleton or defer hero media for heavy pages:
+//
+//	{{ if gt .Budget.JS 300000 }}<div class="skeleton"></div>{{ end }}
+type AssetBudget struct {
+	// JS is the size of the module scripts of the entry points and their
+	// static imports.
+	JS int64
+	// CSS is the size of the stylesheets of the entry points and their
+	// imports.
+	CSS int64
+}
+
+// Total returns the size of all files.
+func (b AssetBudget) Total() int64 {
+	return b.JS + b.CSS
+}
+
+// AssetBudget returns the size of the files loaded for the given chunks,
+// read from fsys, the Vite output directory. Dynamic imports are not
+// included, as they are loaded on demand. It returns an error for files
+// that cannot be found, along with the sizes of the others.
+//
+// The names are the names of the source files, e.g. "src/main.tsx".
+func (m Manifest) AssetBudget(fsys fs.FS, names ...string) (AssetBudget, error) {
+	var (
+		budget AssetBudget
+		errs   []error
+	)
+	size := func(file string) int64 {
+		info, err := fs.Stat(fsys, file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("vite: asset budget: %w", err))
+			return 0
+		}
+		return info.Size()
+	}
+	m.walkPreloads(PreloadOptions{}, names, func(file string, _ int) {
+		budget.JS += size(file)
+	})
+	css, err := m.cssURLs("", names...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, file := range css {
+		budget.CSS += size(file)
+	}
+	return budget, errors.Join(errs...)
+}
+
+// assetBudget returns the asset budget of the given chunks of the indexed
+// manifest. Budgets are computed once per manifest, as the files only
+// change with the build.
+func (h *Handler) assetBudget(idx *manifestIndex, keys []string) AssetBudget {
+	key := strings.Join(keys, "\x00")
+	if budget, ok := idx.budgets.Load(key); ok {
+		return budget.(AssetBudget)
+	}
+	// Concurrent requests may compute the budget twice, which is fine.
+	budget, err := idx.manifest.AssetBudget(h.fs, keys...)
+	if err != nil {
+		h.logger.Debug("Asset budget is incomplete", "chunks", keys, "error", err)
+	}
+	idx.budgets.Store(key, budget)
+	return budget
+}
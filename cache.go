@@ -0,0 +1,168 @@
+package vite
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// AssetClass classifies an output file of the Vite build for caching.
+type AssetClass int
+
+const (
+	// AssetUnknown is a file that is not referenced by the manifest, e.g.
+	// a file of the public directory.
+	AssetUnknown AssetClass = iota
+
+	// AssetVendor is a file with a content hash that belongs to a vendor
+	// chunk, i.e. a chunk built from node_modules or named like "vendor".
+	// It changes only when dependencies are updated.
+	AssetVendor
+
+	// AssetApp is a file with a content hash that belongs to the app.
+	AssetApp
+
+	// AssetUnhashed is a file referenced by the manifest whose name has no
+	// content hash, so its content can change under the same URL.
+	AssetUnhashed
+)
+
+// String returns the name of the class.
+func (c AssetClass) String() string {
+	switch c {
+	case AssetVendor:
+		return "vendor"
+	case AssetApp:
+		return "app"
+	case AssetUnhashed:
+		return "unhashed"
+	default:
+		return "unknown"
+	}
+}
+
+// Immutable returns true if files of the class can be cached for a long
+// time, as their URL changes with their content.
+func (c AssetClass) Immutable() bool {
+	return c == AssetVendor || c == AssetApp
+}
+
+// CacheAdvice is the caching advice for an output file of the Vite build.
+type CacheAdvice struct {
+	// URL is the URL of the file, e.g. "/assets/vendor-4f2e1a.js".
+	URL string
+	// Class is the class of the file.
+	Class AssetClass
+	// CacheControl is the Cache-Control header suggested for the file, see
+	// [DefaultCacheControl].
+	CacheControl string
+}
+
+// CacheControlFunc returns the Cache-Control header for a file served by
+// the handler, given its URL path and its class. An empty result leaves
+// the header unset. See Config.CacheControl.
+type CacheControlFunc func(path string, class AssetClass) string
+
+// DefaultCacheControl caches vendor and app files with a content hash for a
+// year, and makes browsers revalidate unhashed files referenced by the
+// manifest. It leaves the header unset for unknown files.
+func DefaultCacheControl(path string, class AssetClass) string {
+	switch class {
+	case AssetVendor, AssetApp:
+		return "public, max-age=31536000, immutable"
+	case AssetUnhashed:
+		return "no-cache"
+	default:
+		return ""
+	}
+}
+
+// AssetClasses returns the class of each file referenced by the manifest,
+// by file name, e.g. "assets/vendor-4f2e1a.js". See [AssetClass].
+func (m Manifest) AssetClasses() map[string]AssetClass {
+	classes := make(map[string]AssetClass)
+	add := func(file string, vendor bool) {
+		if file == "" {
+			return
+		}
+		class := AssetApp
+		switch {
+		case !isHashedFile(file):
+			class = AssetUnhashed
+		case vendor:
+			class = AssetVendor
+		}
+		// A file shared by app and vendor chunks is an app file.
+		if prev, ok := classes[file]; ok && prev != AssetVendor {
+			return
+		}
+		classes[file] = class
+	}
+	for _, key := range m.keys() {
+		chunk := m[key]
+		if chunk == nil {
+			continue
+		}
+		vendor := isVendorChunk(key, chunk)
+		add(chunk.File, vendor)
+		for _, css := range chunk.CSS {
+			add(css, vendor)
+		}
+		for _, asset := range chunk.Assets {
+			add(asset, vendor)
+		}
+	}
+	return classes
+}
+
+// isVendorChunk returns true if the chunk is built from dependencies, i.e.
+// from node_modules, or is named like "vendor", e.g. with manualChunks.
+func isVendorChunk(key string, chunk *Chunk) bool {
+	if strings.Contains(chunk.Src, "node_modules/") || strings.Contains(key, "node_modules/") {
+		return true
+	}
+	name := chunk.Name
+	if name == "" {
+		name = strings.TrimPrefix(path.Base(chunk.File), "_")
+	}
+	return strings.Contains(strings.ToLower(name), "vendor")
+}
+
+// CacheAdvice returns the caching advice for all files referenced by the
+// manifest, with URLs under the given base, e.g. "/", ordered by URL. The
+// Cache-Control headers are those of [DefaultCacheControl].
+func (m Manifest) CacheAdvice(base string) []CacheAdvice {
+	classes := m.AssetClasses()
+	advice := make([]CacheAdvice, 0, len(classes))
+	for file, class := range classes {
+		u := base + file
+		advice = append(advice, CacheAdvice{
+			URL:          u,
+			Class:        class,
+			CacheControl: DefaultCacheControl(u, class),
+		})
+	}
+	sort.Slice(advice, func(i, j int) bool { return advice[i].URL < advice[j].URL })
+	return advice
+}
+
+// setCacheControl sets the Cache-Control header for a file served by the
+// handler, if configured. The path is relative to the base.
+func (h *Handler) setCacheControl(w http.ResponseWriter, path string) {
+	if h.cacheControl == nil {
+		return
+	}
+	class := AssetUnknown
+	if !h.isDev {
+		class = h.assetClass(strings.TrimPrefix(path, "/"))
+	}
+	if cc := h.cacheControl(path, class); cc != "" {
+		w.Header().Set("Cache-Control", cc)
+	}
+}
+
+// assetClass returns the class of a file of the current manifest.
+func (h *Handler) assetClass(file string) AssetClass {
+	return h.manifestIndex().classes[file]
+}
@@ -0,0 +1,91 @@
+package vite
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// ClientPath is the path of the JavaScript client helper served by the
+// handler in development mode. The handler adds it to every page, so the
+// helpers are available as window.__vite_go, and modules can import it:
+//
+//	import { onFullReload, showErrorOverlay, readEnv } from "/__vite_go/client.js"
+//
+// onFullReload(cb) calls cb before Vite reloads the page, e.g. to save
+// state. showErrorOverlay(err) shows an error, e.g. returned by the
+// backend, in the Vite error overlay; err is a message or an object with
+// message and stack. readEnv(id) returns the data written into the page
+// with [ClientEnvScript].
+const ClientPath = "/__vite_go/client.js"
+
+// clientEnvID is the id of the script element written by ClientEnvScript.
+const clientEnvID = "__vite_go_env"
+
+// ClientEnvScript returns a script element with v encoded as JSON, which
+// the client helper returns from readEnv(), e.g. to bootstrap the frontend
+// with settings of the backend. It is available in templates as viteEnv:
+//
+//	{{ viteEnv .Data }}
+func ClientEnvScript(v any) (template.HTML, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("vite: encode client env: %w", err)
+	}
+	// json.Marshal escapes <, >, and &, so the data cannot end the script.
+	return template.HTML(fmt.Sprintf(`<script type="application/json" id="%s">%s</script>`, clientEnvID, data)), nil
+}
+
+// clientScript returns the script element that loads the client helper.
+func clientScript(nonce string) template.HTML {
+	var attrs string
+	if nonce != "" {
+		attrs = fmt.Sprintf(` nonce="%s"`, template.HTMLEscapeString(nonce))
+	}
+	return template.HTML(fmt.Sprintf(`<script type="module" src="%s"%s></script>`, ClientPath, attrs))
+}
+
+// serveClient serves the client helper.
+func (h *Handler) serveClient(w http.ResponseWriter, r *http.Request) {
+	client, err := url.JoinPath(h.viteURL, "@vite/client")
+	if err != nil {
+		h.serveError(w, r, fmt.Errorf("vite: client helper: %w", err))
+		return
+	}
+	quoted, _ := json.Marshal(client)
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(w, clientJS, quoted, ClientPath, clientEnvID)
+}
+
+const clientJS = `import { createHotContext } from %s;
+
+const hot = createHotContext(%q);
+
+export function onFullReload(cb) {
+  hot.on("vite:beforeFullReload", cb);
+}
+
+export function showErrorOverlay(err) {
+  const ErrorOverlay = customElements.get("vite-error-overlay");
+  if (!ErrorOverlay) {
+    console.error(err);
+    return;
+  }
+  if (typeof err === "string") {
+    err = { message: err, stack: "" };
+  }
+  document.querySelectorAll("vite-error-overlay").forEach((el) => el.close());
+  document.body.appendChild(new ErrorOverlay({ message: String(err.message), stack: String(err.stack || "") }));
+}
+
+export function readEnv(id = %q) {
+  const el = document.getElementById(id);
+  return el ? JSON.parse(el.textContent) : {};
+}
+
+window.__vite_go = { onFullReload, showErrorOverlay, readEnv };
+`
@@ -0,0 +1,78 @@
+package vite
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClientHints are the network-related client hints sent by the browser.
+// See [Save-Data] and [Network Information].
+//
+// [Save-Data]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Save-Data
+// [Network Information]: https://developer.mozilla.org/en-US/docs/Web/API/Network_Information_API
+type ClientHints struct {
+	// SaveData is true if the user opted in to reduced data usage.
+	SaveData bool
+
+	// Downlink is the effective bandwidth in Mbit/s, or zero if unknown.
+	Downlink float64
+
+	// ECT is the effective connection type, i.e. "slow-2g", "2g", "3g",
+	// or "4g", or empty if unknown.
+	ECT string
+}
+
+// ParseClientHints reads the client hints from the request headers.
+func ParseClientHints(r *http.Request) ClientHints {
+	var hints ClientHints
+	hints.SaveData = strings.EqualFold(strings.TrimSpace(r.Header.Get("Save-Data")), "on")
+	if v := r.Header.Get("Downlink"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f > 0 {
+			hints.Downlink = f
+		}
+	}
+	hints.ECT = strings.ToLower(strings.TrimSpace(r.Header.Get("ECT")))
+	return hints
+}
+
+// Constrained returns true if the client asked to save data or is on a
+// slow connection, i.e. an effective connection type of 2G or slower, or a
+// bandwidth below 1 Mbit/s.
+func (c ClientHints) Constrained() bool {
+	if c.SaveData {
+		return true
+	}
+	switch c.ECT {
+	case "slow-2g", "2g":
+		return true
+	}
+	return c.Downlink > 0 && c.Downlink < 1
+}
+
+// Adaptation describes how the handler renders a page for a client.
+type Adaptation struct {
+	// Entry is the entry point of the page, e.g. "src/main.tsx". Set it to
+	// a lighter entry point to serve constrained clients a lite version.
+	Entry string
+
+	// Preload specifies the modulepreload tags of the page.
+	Preload PreloadOptions
+}
+
+// AdaptFunc adapts the rendering of a page to the client hints of a
+// request. It receives the adaptation the handler would use otherwise and
+// returns the one to use.
+type AdaptFunc func(r *http.Request, hints ClientHints, a Adaptation) Adaptation
+
+// DefaultAdaptFunc omits all modulepreload tags for constrained clients,
+// as reported by [ClientHints.Constrained].
+func DefaultAdaptFunc(r *http.Request, hints ClientHints, a Adaptation) Adaptation {
+	if hints.Constrained() {
+		a.Preload.Policy = PreloadNone
+	}
+	return a
+}
+
+// clientHintHeaders are the client hints the handler asks for and varies on.
+const clientHintHeaders = "Save-Data, Downlink, ECT"
@@ -0,0 +1,97 @@
+// Command vite provides maintenance tasks for Go backends serving Vite apps.
+//
+// Usage:
+//
+//	vite prune [flags]
+//
+// The prune command deletes hashed assets of a Vite output directory that
+// only manifests older than the N most recent ones reference. Archive the
+// manifest of every deploy next to the current one (e.g. as
+// .vite/manifest-20240102T150405.json) to keep assets of previous versions
+// around while old pages are still being served.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/olivere/vite"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "prune":
+		err = runPrune(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "vite: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: vite <command> [flags]
+
+Commands:
+  prune   delete assets not referenced by the most recent manifests
+
+Run "vite <command> -h" for the flags of a command.`)
+}
+
+func runPrune(args []string) error {
+	fset := flag.NewFlagSet("prune", flag.ExitOnError)
+	var (
+		dir      = fset.String("dir", "dist", "Vite output directory")
+		pattern  = fset.String("manifests", ".vite/manifest*.json", "glob of manifests, relative to dir")
+		keep     = fset.Int("keep", 3, "number of most recent manifests to keep assets for")
+		dryRun   = fset.Bool("dry-run", false, "print the files to delete without deleting them")
+		noOutput = fset.Bool("q", false, "do not print deleted files")
+	)
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if *keep < 1 {
+		return fmt.Errorf("vite: -keep must be at least 1")
+	}
+
+	versions, err := vite.ReadManifestVersions(os.DirFS(*dir), *pattern)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("vite: no manifests matching %q in %s", *pattern, *dir)
+	}
+
+	if *dryRun {
+		stale, err := vite.StaleAssets(*dir, versions, *keep)
+		if err != nil {
+			return err
+		}
+		for _, name := range stale {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	removed, err := vite.Prune(*dir, versions, *keep)
+	if !*noOutput {
+		for _, name := range removed {
+			fmt.Println(name)
+		}
+	}
+	return err
+}
@@ -1,6 +1,14 @@
 package vite
 
-import "io/fs"
+import (
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Config is the configuration for the handler.
 type Config struct {
@@ -17,6 +25,20 @@ type Config struct {
 	// mode.
 	PublicFS fs.FS
 
+	// PublicPrefix is an optional URL path prefix to serve the public files
+	// under in development mode, e.g. "/public/". If set, only requests
+	// under the prefix are looked up in PublicFS, with the prefix removed,
+	// so that routes of the app never collide with public files. By
+	// default, every request path is looked up in PublicFS first.
+	PublicPrefix string
+
+	// PublicCacheTTL caches for how long whether a request path is a file in
+	// PublicFS in development mode, so that busy dev servers do not look up
+	// every route in the public directory. Files that are added or removed
+	// show up after the TTL, or after Handler.InvalidatePublicCache was
+	// called, e.g. by a file watcher. Zero disables the cache.
+	PublicCacheTTL time.Duration
+
 	// IsDev is true if the server is running in development mode, false
 	// otherwise.
 	IsDev bool
@@ -28,22 +50,389 @@ type Config struct {
 	// [Multi-Page App]: https://vitejs.dev/guide/build.html#multi-page-app
 	ViteEntry string
 
+	// ViteEntries specifies several entry points to include in each page,
+	// e.g. an analytics entry and the app entry. Stylesheets and preloads
+	// shared by them are included once. If set, it takes precedence over
+	// ViteEntry. Use [EntriesToContext] to override it per request.
+	ViteEntries []string
+
+	// DefaultEntry is the entry point loaded from the dev server if neither
+	// ViteEntry nor ViteEntries are set, e.g. "src/main.ts" for Vue or
+	// Svelte apps. It defaults to "src/main.tsx". It is unused in
+	// production mode, where the first entry point of the manifest is used.
+	DefaultEntry string
+
 	// ViteURL is the URL of the Vite server, used to load the Vite client
 	// in development mode (and defaults to http://localhost:5173).
 	// It is unused in production mode.
 	ViteURL string
 
+	// Base is the public base path of the Vite app, i.e. the base option
+	// in vite.config.ts, e.g. "/app/". It is prepended to the URLs of
+	// scripts, stylesheets, and assets, and stripped from request paths
+	// before looking up files. It defaults to "/".
+	Base string
+
 	// ViteManifest is the path to the Vite manifest file. This is used in
 	// production mode to load the manifest file and map the original file
 	// paths to the transformed file paths. If this is not provided, the
 	// default path is ".vite/manifest.json".
 	ViteManifest string
 
+	// ViteRoutes is the optional path of a routes file in FS, e.g.
+	// ".vite/routes.json", written by a companion Vite plugin for frontends
+	// with file-based routing. The handler renders the page of each route
+	// with the entry point of the route, so that the backend routing stays
+	// in sync with the frontend. See [Route].
+	ViteRoutes string
+
+	// Manifest is a pre-parsed Vite manifest, e.g. the result of
+	// [MergeManifests]. If set, it is used instead of reading ViteManifest
+	// from FS in production mode.
+	Manifest *Manifest
+
 	// ViteTemplate specifies a configuration template used to scaffold the Vite
 	// project. See [Scaffolding Your First Vite Project].
 	//
+	// It decides which preamble is injected in development mode, e.g. the
+	// one for React Fast Refresh with [React]. If it is not set, no preamble
+	// is injected. Earlier versions injected the React preamble by default,
+	// so React apps have to set it now; the handler logs a warning in
+	// development mode until it is set, use [None] to silence it.
+	//
 	// [Scaffolding Your First Vite Project]: https://vitejs.dev/guide/#scaffolding-your-first-vite-project
 	ViteTemplate Scaffolding
+
+	// SSR is an optional renderer for server-side rendering. If set, the
+	// handler calls it for every page it renders and makes the result
+	// available to templates as {{ .SSR }}. The fallback template renders it
+	// inside the root element. If rendering fails, the error is logged and
+	// the page is served without server-rendered content.
+	SSR SSRRenderer
+
+	// TemplateEngine is an optional engine used to render pages. If set, the
+	// handler looks up templates in the engine before falling back to the
+	// templates registered with [Handler.RegisterTemplate] and, finally, to
+	// the built-in fallback template.
+	TemplateEngine TemplateEngine
+
+	// FragmentTemplate is an optional html/template used by [HTMLFragment]
+	// instead of the built-in one, e.g. to add attributes, reorder tags, or
+	// drop the preamble. It is executed with the [PageData] of the fragment,
+	// and can use the functions urljoin, see [net/url.JoinPath], and
+	// scriptAttrs, which returns the attributes of the module script of an
+	// entry point, see ScriptAttributes. It is unused by the handler.
+	FragmentTemplate string
+
+	// TemplateFS is an optional file system to register templates from, with
+	// the files matching TemplatePatterns. See [Handler.RegisterTemplatesFS].
+	TemplateFS fs.FS
+
+	// TemplatePatterns are the patterns of the template files in TemplateFS.
+	// It defaults to "*.html".
+	TemplatePatterns []string
+
+	// TemplateChain returns the names of the templates to try for a page,
+	// in order. It defaults to [DefaultTemplateChain], which tries the path,
+	// its sections, and the index, e.g. "/admin/users", "/admin", and
+	// "index.html" for "/admin/users", before the built-in fallback
+	// template. Each name also matches templates registered with or
+	// without the leading slash and the ".html" extension. Paths without a
+	// template of their own and no file are rendered with the template of
+	// the first section in the chain; the index only applies to pages that
+	// are rendered anyway, e.g. the routes of ViteRoutes.
+	TemplateChain TemplateChainFunc
+
+	// ReloadTemplates parses the templates registered from files, e.g. with
+	// TemplateFS, again on each request, so that changes show up without
+	// restarting the server. It only has an effect in development mode.
+	ReloadTemplates bool
+
+	// BodyStreamFunc is an optional callback that streams the body of a page,
+	// e.g. from a streaming SSR renderer. If set, the handler writes and
+	// flushes everything up to the {{ .SSR }} slot of the template right
+	// away, then calls BodyStreamFunc, and finally writes the rest of the
+	// page. It takes precedence over SSR.
+	BodyStreamFunc BodyStreamFunc
+
+	// PreloadPolicy specifies which imports of the entry point are emitted
+	// as modulepreload links in production mode. It defaults to
+	// [PreloadAll].
+	PreloadPolicy PreloadPolicy
+
+	// PreloadPolicies overrides PreloadPolicy per entry point. The key is
+	// the source file of the entry point, e.g. "src/admin.tsx".
+	PreloadPolicies map[string]PreloadPolicy
+
+	// ScriptAttributes adds attributes to the module script of an entry
+	// point, e.g. data attributes used by a loader. The key is the source
+	// file of the entry point, e.g. "src/admin.tsx".
+	ScriptAttributes map[string]ScriptAttributes
+
+	// MaxPreloads limits the number of modulepreload links per page in
+	// production mode. Imports closer to the entry point are preloaded
+	// first. Zero means no limit.
+	MaxPreloads int
+
+	// PreloadFetchPriority annotates modulepreload links with fetchpriority
+	// hints: "high" for the entry point and its direct imports, "low" for
+	// everything further down the import graph.
+	PreloadFetchPriority bool
+
+	// RespectClientHints makes the handler read the Save-Data, Downlink, and
+	// ECT client hints and adapt pages for constrained clients with
+	// AdaptFunc. The handler also advertises the hints with an Accept-CH
+	// header, and adds them to the Vary header.
+	RespectClientHints bool
+
+	// AdaptFunc decides how to render pages for a client when
+	// RespectClientHints is true. It defaults to [DefaultAdaptFunc], which
+	// drops all modulepreload tags for constrained clients.
+	AdaptFunc AdaptFunc
+
+	// BotDetector reports whether a request comes from a crawler. If set,
+	// templates can check {{ .IsBot }} to render a variant for crawlers,
+	// e.g. with prerender metadata. See [NewBotDetector].
+	BotDetector BotDetector
+
+	// SSRForBotsOnly restricts server-side rendering with SSR to requests
+	// from crawlers, as reported by BotDetector. Everyone else gets the
+	// client-side rendered shell. It requires BotDetector to be set.
+	SSRForBotsOnly bool
+
+	// SSRHeaders is the allowlist of request headers passed to SSR, if it
+	// implements [SSRRequestRenderer], e.g. "Accept-Language". No headers
+	// are passed by default.
+	SSRHeaders []string
+
+	// SSRCookies is the allowlist of cookies passed to SSR, if it
+	// implements [SSRRequestRenderer], e.g. "session". No cookies are
+	// passed by default.
+	SSRCookies []string
+
+	// VitalsRecorder enables Core Web Vitals reporting. If set, the handler
+	// injects a script into every page that posts the vitals of the page to
+	// VitalsPath, and passes them to the recorder. The script uses the
+	// nonce set with [NonceToContext], if any.
+	VitalsRecorder VitalsRecorder
+
+	// VitalsPath is the path web vitals are posted to. It defaults to
+	// [DefaultVitalsPath].
+	VitalsPath string
+
+	// AssetsPrefix is the URL path prefix of the assets built by Vite. It
+	// defaults to "/assets/". Requests for missing files under the prefix
+	// are counted, see [Handler.MissingAssets].
+	AssetsPrefix string
+
+	// MissingAssetsThreshold is the number of requests for missing assets
+	// within MissingAssetsWindow that triggers OnMissingAssets. A burst of
+	// them is often a sign of a mismatch between HTML and manifest after a
+	// deploy.
+	MissingAssetsThreshold int
+
+	// MissingAssetsWindow is the window for MissingAssetsThreshold. It
+	// defaults to one minute.
+	MissingAssetsWindow time.Duration
+
+	// OnMissingAssets is called when MissingAssetsThreshold is reached, at
+	// most once per window. It is called synchronously while serving the
+	// request, so it should return quickly.
+	OnMissingAssets MissingAssetsFunc
+
+	// DevServerCheck makes NewHandler check that the Vite dev server is
+	// running in development mode, by probing ViteURL and then each of
+	// DevServerCandidates. If one of them responds, it is used as ViteURL.
+	// If none does, NewHandler either returns an error or falls back to
+	// production mode, see [DevServerCheck].
+	DevServerCheck DevServerCheck
+
+	// DevServerCandidates are URLs to probe if the dev server does not
+	// respond on ViteURL, e.g. "http://localhost:5174" if Vite picked the
+	// next port because 5173 was in use.
+	DevServerCandidates []string
+
+	// DevServerTimeout is the timeout for probing a dev server URL. It
+	// defaults to one second.
+	DevServerTimeout time.Duration
+
+	// ProbeDevServer makes the handler check that the Vite dev server is
+	// reachable before rendering a page in development mode, at most every
+	// two seconds. If it is not, pages omit the Vite client and the entry
+	// points, and the fallback template shows a banner instead, so that
+	// server-rendered pages stay usable without the frontend toolchain.
+	// Templates can check {{ .ViteUnreachable }}.
+	ProbeDevServer bool
+
+	// FallbackFS is the file system to serve from when falling back to
+	// production mode with DevServerCheckFallback. It defaults to the
+	// "dist" directory of FS.
+	FallbackFS fs.FS
+
+	// ServePageData makes the handler reply to page requests that prefer
+	// JSON, i.e. with "Accept: application/json", with the title, metadata,
+	// entry points, and data of the page instead of the HTML, e.g. for
+	// client-side route transitions. See [PageDataJSON].
+	ServePageData bool
+
+	// ServeIndexHTML serves the index.html of the Vite build for pages in
+	// production mode if no templates are registered, instead of rendering
+	// the fallback template, e.g. when migrating from static hosting. The
+	// metadata and scripts of the page are injected before </head>, except
+	// for a base URL, which goes right after <head>, and a title in the
+	// metadata replaces the one of the page. Server-rendered
+	// HTML is injected into the first element with the id "root" or "app",
+	// as in the Vite scaffolds, if it is empty. The page is read once per
+	// loaded manifest.
+	ServeIndexHTML bool
+
+	// ServeAssetsManifest makes the handler serve the URL, size, and
+	// integrity hash of all output files as JSON at AssetsManifestPath in
+	// production mode, e.g. for CDN warmers and security scanners. See
+	// [Manifest.AssetInfos].
+	ServeAssetsManifest bool
+
+	// ValidateHTML checks each rendered page in development mode and logs
+	// a warning about unclosed tags, duplicate ids, and scripts outside of
+	// <head> and <body>, e.g. to catch templates that do not fit together
+	// with injected tags. See [ValidateHTML].
+	ValidateHTML bool
+
+	// ModulePlacement specifies where the module scripts of the entry
+	// points are placed, see [ModulePlacement]. With PlaceAtBodyEnd,
+	// templates render the scripts with {{ .BodyModules }}, the fallback
+	// template does so before </body>, and fragments move them from Tags
+	// to BodyTags.
+	ModulePlacement ModulePlacement
+
+	// NoScriptHTML is shown to browsers without JavaScript, e.g. a notice
+	// or a static version of the content for crawlers. The fallback
+	// template renders it in a <noscript> element in the body, custom
+	// templates with {{ .NoScript }}.
+	NoScriptHTML template.HTML
+
+	// DisableResourceHints turns off the preconnect and dns-prefetch links
+	// the handler adds to the metadata of pages if scripts and stylesheets
+	// are loaded from another origin than the page, i.e. from a Base on a
+	// CDN in production mode, or from ViteURL in development mode.
+	DisableResourceHints bool
+
+	// CacheControl optionally returns the Cache-Control header for the
+	// files served by the handler, by the class of the file in the
+	// manifest. Use [DefaultCacheControl] to cache files with a content
+	// hash for a year. All files are of class AssetUnknown in development
+	// mode.
+	CacheControl CacheControlFunc
+
+	// Tracer is an optional tracer for spans around rendering pages, e.g.
+	// an adapter for OpenTelemetry. See [Tracer].
+	Tracer Tracer
+
+	// Logger is the logger for warnings, e.g. about missing templates, and
+	// debug messages, e.g. about how entry points are resolved. It defaults
+	// to [slog.Default].
+	Logger *slog.Logger
+
+	// NotFoundHandler is an optional handler for requests of files that do
+	// not exist, e.g. to render a branded 404 page. It defaults to
+	// [http.NotFound].
+	NotFoundHandler http.Handler
+
+	// ErrorHandler is an optional callback for errors while rendering a
+	// page, e.g. to render a branded 500 page. It defaults to replying with
+	// "Internal server error" and status 500.
+	ErrorHandler ErrorHandlerFunc
+}
+
+// preloadOptions returns the default preload options.
+func (c Config) preloadOptions() PreloadOptions {
+	return PreloadOptions{
+		Policy:        c.PreloadPolicy,
+		MaxPreloads:   c.MaxPreloads,
+		FetchPriority: c.PreloadFetchPriority,
+	}
+}
+
+// preloadOptionsFor returns the preload options for the given entry point.
+func preloadOptionsFor(opts PreloadOptions, policies map[string]PreloadPolicy, entry string) PreloadOptions {
+	if p, ok := policies[entry]; ok {
+		opts.Policy = p
+	}
+	return opts
+}
+
+// PreloadPolicy specifies which imports of an entry point are preloaded
+// with <link rel="modulepreload"> tags.
+type PreloadPolicy int
+
+const (
+	// PreloadAll preloads the entry point and all of its imports,
+	// recursively. This is the default.
+	PreloadAll PreloadPolicy = iota
+
+	// PreloadDirect preloads the entry point and its direct imports only.
+	PreloadDirect
+
+	// PreloadNone emits no modulepreload tags at all.
+	PreloadNone
+)
+
+// ModulePlacement specifies where the module scripts of the entry points,
+// including the Vite client in development mode, are placed in a page.
+// Stylesheets, the preamble, and modulepreload links stay in the head.
+type ModulePlacement int
+
+const (
+	// PlaceInHead places the module scripts in the <head> element. This is
+	// the default.
+	PlaceInHead ModulePlacement = iota
+
+	// PlaceAtBodyEnd places the module scripts at the end of the <body>
+	// element, e.g. for apps whose scripts expect the DOM to be parsed.
+	PlaceAtBodyEnd
+)
+
+// BodyStreamFunc streams the body of a page to w. The head of the page has
+// already been written when it is called, so errors can only be logged.
+type BodyStreamFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ScriptAttributes are HTML attributes of a script element, by name. An
+// empty value adds a boolean attribute, e.g. "async".
+type ScriptAttributes map[string]string
+
+// html returns the attributes as HTML, with a leading space, ordered by
+// name.
+func (a ScriptAttributes) html() template.HTMLAttr {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteByte(' ')
+		sb.WriteString(template.HTMLEscapeString(name))
+		if value := a[name]; value != "" {
+			sb.WriteString(`="`)
+			sb.WriteString(template.HTMLEscapeString(value))
+			sb.WriteByte('"')
+		}
+	}
+	return template.HTMLAttr(sb.String())
+}
+
+// ErrorHandlerFunc replies to a request that failed with err. See
+// Config.ErrorHandler.
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// defaultEntry returns the entry point of the dev server if the
+// configuration sets none.
+func (c Config) defaultEntry() string {
+	if c.DefaultEntry != "" {
+		return c.DefaultEntry
+	}
+	return "src/main.tsx"
 }
 
 // Scaffolding represents various templates provided by Vite that can be used
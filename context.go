@@ -4,17 +4,125 @@ import "context"
 
 type contextKey string
 
-var scriptsKey = contextKey("scripts")
+var renderOptionsKey = contextKey("renderOptions")
+
+// RenderOptions are the per-request inputs for rendering a page. They are
+// carried in the request context as a single value, see
+// [RenderOptionsToContext]. The other context helpers of this package, e.g.
+// [MetadataToContext], set individual fields of the render options.
+type RenderOptions struct {
+	// Metadata is the metadata of the page. If it is nil, the default
+	// metadata of the handler is used.
+	Metadata *Metadata
+
+	// Scripts are injected into the head of the page.
+	Scripts string
+
+	// Entry overrides the entry point of the page, e.g. "src/admin.tsx".
+	Entry string
+
+	// Entries overrides the entry points of the page with several ones. It
+	// takes precedence over Entry.
+	Entries []string
+
+	// Nonce is the Content Security Policy nonce for inline scripts
+	// generated by the handler.
+	Nonce string
+
+	// Data is passed to templates as {{ .Data }}.
+	Data any
+}
+
+// entries returns the entry points of the render options, if any.
+func (o RenderOptions) entries() []string {
+	if len(o.Entries) > 0 {
+		return o.Entries
+	}
+	if o.Entry != "" {
+		return []string{o.Entry}
+	}
+	return nil
+}
+
+// RenderOptionsFromContext returns the render options from the context.
+func RenderOptionsFromContext(ctx context.Context) RenderOptions {
+	if opts, ok := ctx.Value(renderOptionsKey).(RenderOptions); ok {
+		return opts
+	}
+	return RenderOptions{}
+}
+
+// RenderOptionsToContext sets the render options in the context, replacing
+// all options set before.
+func RenderOptionsToContext(ctx context.Context, opts RenderOptions) context.Context {
+	return context.WithValue(ctx, renderOptionsKey, opts)
+}
+
+// updateRenderOptions sets the render options in the context after
+// applying f to the current ones.
+func updateRenderOptions(ctx context.Context, f func(*RenderOptions)) context.Context {
+	opts := RenderOptionsFromContext(ctx)
+	f(&opts)
+	return RenderOptionsToContext(ctx, opts)
+}
 
 // ScriptsFromContext returns the scripts to be injected in the HTML.
 func ScriptsFromContext(ctx context.Context) string {
-	if md, ok := ctx.Value(scriptsKey).(string); ok {
-		return md
-	}
-	return ""
+	return RenderOptionsFromContext(ctx).Scripts
 }
 
 // ScriptsToContext sets the scripts to be injected in the HTML.
 func ScriptsToContext(ctx context.Context, scripts string) context.Context {
-	return context.WithValue(ctx, scriptsKey, scripts)
+	return updateRenderOptions(ctx, func(opts *RenderOptions) {
+		opts.Scripts = scripts
+	})
+}
+
+// NonceFromContext returns the Content Security Policy nonce for inline
+// scripts generated by the handler.
+func NonceFromContext(ctx context.Context) string {
+	return RenderOptionsFromContext(ctx).Nonce
+}
+
+// NonceToContext sets the Content Security Policy nonce for inline scripts
+// generated by the handler, e.g. the web vitals script. Use the same nonce
+// in the script-src directive of the Content-Security-Policy header.
+func NonceToContext(ctx context.Context, nonce string) context.Context {
+	return updateRenderOptions(ctx, func(opts *RenderOptions) {
+		opts.Nonce = nonce
+	})
+}
+
+// EntryFromContext returns the primary entry point to render the page
+// with, if any.
+func EntryFromContext(ctx context.Context) string {
+	if entries := RenderOptionsFromContext(ctx).entries(); len(entries) > 0 {
+		return entries[0]
+	}
+	return ""
+}
+
+// EntryToContext sets the entry point to render the page with, e.g.
+// "src/admin.tsx", so that a single handler serves pages of several entry
+// points. It overrides Config.ViteEntry and Config.ViteEntries, and the
+// entry points set with [EntriesToContext] before.
+func EntryToContext(ctx context.Context, entry string) context.Context {
+	return updateRenderOptions(ctx, func(opts *RenderOptions) {
+		opts.Entry = entry
+		opts.Entries = nil
+	})
+}
+
+// EntriesFromContext returns the entry points to render the page with.
+func EntriesFromContext(ctx context.Context) []string {
+	return RenderOptionsFromContext(ctx).entries()
+}
+
+// EntriesToContext sets the entry points to render the page with, e.g.
+// "src/analytics.ts" and "src/main.tsx". It overrides Config.ViteEntry and
+// Config.ViteEntries for the request.
+func EntriesToContext(ctx context.Context, entries ...string) context.Context {
+	return updateRenderOptions(ctx, func(opts *RenderOptions) {
+		opts.Entries = entries
+	})
 }
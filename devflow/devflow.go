@@ -0,0 +1,173 @@
+/*
+Package devflow helps running the Vite dev server and the Go backend in
+containers, e.g. with Docker Compose or in a dev container.
+
+Inside a container network, the Go backend reaches the dev server under a
+service hostname like "http://vite:5173", while the browser on the host
+reaches it under a published port like "http://localhost:5173". Script
+tags and the HMR websocket must use the browser-facing URL.
+
+Example:
+
+	setup, err := devflow.Resolve(os.Getenv("VITE_URL"), devflow.Options{})
+	if err != nil { ... }
+	v, err := vite.NewHandler(vite.Config{
+		FS:      os.DirFS("frontend"),
+		IsDev:   true,
+		ViteURL: setup.BrowserURL,
+	})
+
+Configure Vite with the HMR settings in setup.HMR, e.g. by passing
+setup.HMR.Env() to the dev server and reading the variables in
+vite.config.ts.
+*/
+package devflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// InContainer reports whether the process runs inside a container, such as
+// Docker, Podman, Kubernetes, or a dev container. Detection is best-effort.
+func InContainer() bool {
+	for _, name := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(name); err == nil {
+			return true
+		}
+	}
+	for _, name := range []string{"KUBERNETES_SERVICE_HOST", "REMOTE_CONTAINERS", "CODESPACES"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	if data, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		s := string(data)
+		for _, marker := range []string{"docker", "kubepods", "containerd", "libpod"} {
+			if strings.Contains(s, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Options configures [Resolve].
+type Options struct {
+	// BrowserHost is the host the browser reaches the dev server on. It
+	// defaults to "localhost".
+	BrowserHost string
+
+	// BrowserPort is the port the browser reaches the dev server on, i.e.
+	// the published port of the dev server container. It defaults to the
+	// port of the service URL.
+	BrowserPort int
+
+	// Force maps the service URL even if the process does not run inside
+	// a container.
+	Force bool
+}
+
+// Setup is the result of [Resolve].
+type Setup struct {
+	// ServiceURL is the URL the Go backend reaches the dev server on,
+	// e.g. "http://vite:5173".
+	ServiceURL string
+
+	// BrowserURL is the URL the browser reaches the dev server on, e.g.
+	// "http://localhost:5173". Use it as vite.Config.ViteURL.
+	BrowserURL string
+
+	// HMR is the HMR configuration for the dev server.
+	HMR HMR
+}
+
+// Resolve maps the URL of the dev server as seen by the Go backend to the
+// URL as seen by the browser. Outside of containers, both are the same,
+// unless Options.Force is set.
+func Resolve(serviceURL string, opts Options) (Setup, error) {
+	if serviceURL == "" {
+		serviceURL = "http://localhost:5173"
+	}
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return Setup{}, fmt.Errorf("devflow: invalid service URL: %w", err)
+	}
+	if u.Host == "" {
+		return Setup{}, fmt.Errorf("devflow: invalid service URL %q", serviceURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	browser := *u
+	if opts.Force || InContainer() {
+		host := opts.BrowserHost
+		if host == "" {
+			host = "localhost"
+		}
+		if opts.BrowserPort > 0 {
+			port = strconv.Itoa(opts.BrowserPort)
+		}
+		browser.Host = net.JoinHostPort(host, port)
+	}
+
+	clientPort, _ := strconv.Atoi(port)
+	protocol := "ws"
+	if browser.Scheme == "https" {
+		protocol = "wss"
+	}
+	return Setup{
+		ServiceURL: u.String(),
+		BrowserURL: browser.String(),
+		HMR: HMR{
+			Protocol:   protocol,
+			Host:       browser.Hostname(),
+			ClientPort: clientPort,
+		},
+	}, nil
+}
+
+// HMR is the server.hmr configuration of Vite, for the HMR client in the
+// browser to connect to the dev server through the published port.
+type HMR struct {
+	Protocol   string `json:"protocol"`
+	Host       string `json:"host"`
+	ClientPort int    `json:"clientPort"`
+}
+
+// String returns the configuration as a JavaScript object, to be used as
+// server.hmr in vite.config.ts.
+func (h HMR) String() string {
+	data, _ := json.Marshal(h)
+	return string(data)
+}
+
+// Env returns the configuration as environment variables for the dev
+// server process, i.e. VITE_HMR_PROTOCOL, VITE_HMR_HOST, and
+// VITE_HMR_CLIENT_PORT. Read them in vite.config.ts:
+//
+//	server: {
+//	  hmr: process.env.VITE_HMR_HOST ? {
+//	    protocol: process.env.VITE_HMR_PROTOCOL,
+//	    host: process.env.VITE_HMR_HOST,
+//	    clientPort: Number(process.env.VITE_HMR_CLIENT_PORT),
+//	  } : undefined,
+//	},
+func (h HMR) Env() []string {
+	return []string{
+		"VITE_HMR_PROTOCOL=" + h.Protocol,
+		"VITE_HMR_HOST=" + h.Host,
+		"VITE_HMR_CLIENT_PORT=" + strconv.Itoa(h.ClientPort),
+	}
+}
@@ -0,0 +1,50 @@
+package devflow_test
+
+import (
+	"testing"
+
+	"github.com/olivere/vite/devflow"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		serviceURL string
+		opts       devflow.Options
+		browserURL string
+		hmr        string
+	}{
+		{
+			serviceURL: "http://vite:5173",
+			opts:       devflow.Options{Force: true},
+			browserURL: "http://localhost:5173",
+			hmr:        `{"protocol":"ws","host":"localhost","clientPort":5173}`,
+		},
+		{
+			serviceURL: "http://frontend:5173/app/",
+			opts:       devflow.Options{Force: true, BrowserHost: "dev.example.com", BrowserPort: 8443},
+			browserURL: "http://dev.example.com:8443/app/",
+			hmr:        `{"protocol":"ws","host":"dev.example.com","clientPort":8443}`,
+		},
+		{
+			serviceURL: "https://vite",
+			opts:       devflow.Options{Force: true},
+			browserURL: "https://localhost:443",
+			hmr:        `{"protocol":"wss","host":"localhost","clientPort":443}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serviceURL, func(t *testing.T) {
+			setup, err := devflow.Resolve(tt.serviceURL, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, have := tt.browserURL, setup.BrowserURL; want != have {
+				t.Errorf("expected browser URL %q, got %q", want, have)
+			}
+			if want, have := tt.hmr, setup.HMR.String(); want != have {
+				t.Errorf("expected HMR config %s, got %s", want, have)
+			}
+		})
+	}
+}
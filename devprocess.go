@@ -0,0 +1,210 @@
+package vite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DevServerConfig is the configuration for a Vite dev server process.
+type DevServerConfig struct {
+	// Dir is the working directory of the process, i.e. the root of the
+	// Vite app. It defaults to the working directory of the calling
+	// process.
+	Dir string
+
+	// Command is the executable to run. It defaults to "npm".
+	Command string
+
+	// Args are the arguments passed to Command. If Command is "npm" and
+	// Args is nil, it defaults to "run dev".
+	Args []string
+
+	// Env specifies the environment of the process. If it is nil, the
+	// process uses the environment of the calling process.
+	Env []string
+
+	// Logger receives the output of the process, one record per line. It
+	// defaults to [slog.Default].
+	Logger *slog.Logger
+
+	// StartTimeout is the time StartDevServer waits for the dev server to
+	// print its URL. It defaults to 30 seconds.
+	StartTimeout time.Duration
+}
+
+// DevServer is a Vite dev server running as a child process.
+type DevServer struct {
+	cmd       *exec.Cmd
+	url       string
+	exited    chan struct{}
+	err       error // set before exited is closed
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// devServerURLRegexp matches the line in which Vite prints the local URL
+// of the dev server, e.g. "  ➜  Local:   http://localhost:5173/".
+var devServerURLRegexp = regexp.MustCompile(`Local:\s+(https?://\S+)`)
+
+// ansiRegexp matches ANSI escape sequences, e.g. for colors.
+var ansiRegexp = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// StartDevServer runs the Vite dev server, e.g. "npm run dev", and waits
+// until it prints its URL. This allows to start the complete development
+// environment with a single command:
+//
+//	dev, err := vite.StartDevServer(ctx, vite.DevServerConfig{Dir: "frontend"})
+//	if err != nil { ... }
+//	defer dev.Close()
+//
+//	v, err := vite.NewHandler(vite.Config{
+//		FS:      os.DirFS("frontend"),
+//		IsDev:   true,
+//		ViteURL: dev.URL(),
+//	})
+//
+// The process is stopped when ctx is done.
+func StartDevServer(ctx context.Context, config DevServerConfig) (*DevServer, error) {
+	if config.Command == "" {
+		config.Command = "npm"
+	}
+	if config.Command == "npm" && config.Args == nil {
+		config.Args = []string{"run", "dev"}
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	if config.StartTimeout <= 0 {
+		config.StartTimeout = 30 * time.Second
+	}
+
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Dir = config.Dir
+	cmd.Env = config.Env
+	setProcessGroup(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("vite: dev server stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("vite: dev server stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("vite: start dev server: %w", err)
+	}
+
+	d := &DevServer{
+		cmd:    cmd,
+		exited: make(chan struct{}),
+	}
+
+	urls := make(chan string, 1)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.logOutput(stdout, config.Logger, slog.LevelInfo, urls)
+	}()
+	go func() {
+		defer wg.Done()
+		d.logOutput(stderr, config.Logger, slog.LevelWarn, urls)
+	}()
+	go func() {
+		wg.Wait()
+		err := cmd.Wait()
+		if err == nil {
+			err = errors.New("vite: dev server exited")
+		} else {
+			err = fmt.Errorf("vite: dev server exited: %w", err)
+		}
+		d.err = err
+		close(d.exited)
+	}()
+
+	timer := time.NewTimer(config.StartTimeout)
+	defer timer.Stop()
+	select {
+	case d.url = <-urls:
+	case <-d.exited:
+		return nil, d.err
+	case <-ctx.Done():
+		d.Close()
+		return nil, ctx.Err()
+	case <-timer.C:
+		d.Close()
+		return nil, fmt.Errorf("vite: dev server did not print its URL within %v", config.StartTimeout)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.Close()
+		case <-d.exited:
+		}
+	}()
+	return d, nil
+}
+
+// logOutput logs the lines of r, and sends the URL of the dev server to
+// urls once it shows up.
+func (d *DevServer) logOutput(r io.Reader, logger *slog.Logger, level slog.Level, urls chan<- string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(ansiRegexp.ReplaceAllString(scanner.Text(), ""))
+		if line == "" {
+			continue
+		}
+		logger.Log(context.Background(), level, line, "process", "vite")
+		if m := devServerURLRegexp.FindStringSubmatch(line); m != nil {
+			select {
+			case urls <- strings.TrimSuffix(m[1], "/"):
+			default:
+			}
+		}
+	}
+}
+
+// URL returns the URL of the dev server, e.g. "http://localhost:5173".
+// Use it as Config.ViteURL.
+func (d *DevServer) URL() string {
+	return d.url
+}
+
+// Done returns a channel that is closed when the process exits.
+func (d *DevServer) Done() <-chan struct{} {
+	return d.exited
+}
+
+// Close stops the dev server. It waits up to 5 seconds for the process to
+// exit before killing it.
+func (d *DevServer) Close() error {
+	d.closeOnce.Do(func() {
+		if err := terminateProcess(d.cmd); err != nil {
+			select {
+			case <-d.exited:
+				return
+			default:
+			}
+			d.closeErr = fmt.Errorf("vite: stop dev server: %w", err)
+			return
+		}
+		select {
+		case <-d.exited:
+		case <-time.After(5 * time.Second):
+			killProcess(d.cmd)
+			<-d.exited
+		}
+	})
+	return d.closeErr
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package vite
+
+import "os/exec"
+
+// setProcessGroup is a no-op on this platform.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcess kills the process, as there are no signals to ask it
+// to terminate on this platform.
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcess kills the process.
+func killProcess(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -0,0 +1,77 @@
+package vite_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olivere/vite"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStartDevServer(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var out syncBuffer
+	dev, err := vite.StartDevServer(context.Background(), vite.DevServerConfig{
+		Command: "sh",
+		Args:    []string{"-c", `printf '\n  \033[32mVITE v5.2.0\033[39m  ready in 120 ms\n\n  ➜  Local:   \033[36mhttp://localhost:5174/\033[39m\n'; exec sleep 30`},
+		Logger:  slog.New(slog.NewTextHandler(&out, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "http://localhost:5174", dev.URL(); want != have {
+		t.Fatalf("expected URL %q, got %q", want, have)
+	}
+	if want := "VITE v5.2.0  ready in 120 ms"; !strings.Contains(out.String(), want) {
+		t.Fatalf("expected log to contain %q, got:\n%s", want, out.String())
+	}
+
+	if err := dev.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-dev.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the dev server to exit")
+	}
+}
+
+func TestStartDevServerExits(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	_, err := vite.StartDevServer(context.Background(), vite.DevServerConfig{
+		Command: "sh",
+		Args:    []string{"-c", "echo 'vite: command not found' >&2; exit 127"},
+		Logger:  slog.New(slog.NewTextHandler(&syncBuffer{}, nil)),
+	})
+	if err == nil {
+		t.Fatal("expected an error if the dev server exits")
+	}
+}
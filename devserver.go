@@ -0,0 +1,143 @@
+package vite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DevServerCheck specifies whether and how the handler checks that the
+// Vite dev server is running when it is created in development mode.
+type DevServerCheck int
+
+const (
+	// DevServerCheckNone does not check the dev server. This is the
+	// default.
+	DevServerCheckNone DevServerCheck = iota
+
+	// DevServerCheckRequire makes NewHandler return an error if the dev
+	// server is not reachable.
+	DevServerCheckRequire
+
+	// DevServerCheckFallback makes the handler fall back to production
+	// mode if the dev server is not reachable.
+	DevServerCheckFallback
+)
+
+// probeDevServer returns the first of the candidate URLs a Vite dev server
+// responds on. It checks for the Vite client script, which every Vite dev
+// server serves.
+func probeDevServer(ctx context.Context, candidates []string, timeout time.Duration) (string, error) {
+	client := &http.Client{Timeout: timeout}
+	var errs []string
+	for _, candidate := range candidates {
+		u, err := url.JoinPath(candidate, "@vite/client")
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", candidate, err))
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", candidate, err))
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", candidate, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			errs = append(errs, fmt.Sprintf("%s: status %d", candidate, resp.StatusCode))
+			continue
+		}
+		return candidate, nil
+	}
+	return "", fmt.Errorf("vite: %w (is \"npm run dev\" running?): %s", ErrViteUnreachable, strings.Join(errs, "; "))
+}
+
+// WaitForViteServer waits until the Vite dev server at url responds,
+// polling it until ctx is done. Call it before serving requests to avoid
+// blank pages when the backend is up before the dev server, e.g. with
+// "go run" and a dev server started with [StartDevServer] or externally.
+func WaitForViteServer(ctx context.Context, url string) error {
+	const interval = 200 * time.Millisecond
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		_, err := probeDevServer(ctx, []string{url}, time.Second)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("vite: waiting for dev server: %w", errors.Join(ctx.Err(), err))
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDevServer checks the dev server of a configuration in development
+// mode, and returns the configuration to use.
+func checkDevServer(config Config) (Config, error) {
+	candidates := append([]string{config.ViteURL}, config.DevServerCandidates...)
+	timeout := config.DevServerTimeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	viteURL, err := probeDevServer(context.Background(), candidates, timeout)
+	if err == nil {
+		config.ViteURL = viteURL
+		return config, nil
+	}
+	if config.DevServerCheck != DevServerCheckFallback {
+		return config, err
+	}
+
+	config.Logger.Warn(
+		"Vite dev server not reachable, falling back to production mode",
+		"error", err,
+	)
+	config.IsDev = false
+	if config.FallbackFS != nil {
+		config.FS = config.FallbackFS
+	} else if config.FS, err = fs.Sub(config.FS, "dist"); err != nil {
+		return config, fmt.Errorf("vite: unable to fall back to production mode: %w", err)
+	}
+	return config, nil
+}
+
+// devServerProbeInterval is how long the result of probing the dev server
+// before rendering a page is reused, see Config.ProbeDevServer.
+const devServerProbeInterval = 2 * time.Second
+
+// devServerProbe reports whether the dev server is reachable, probing it at
+// most once per devServerProbeInterval.
+type devServerProbe struct {
+	url     string
+	timeout time.Duration
+
+	mu        sync.Mutex
+	checked   time.Time
+	reachable bool
+}
+
+// isReachable returns true if the dev server responded to the last probe.
+func (p *devServerProbe) isReachable(ctx context.Context) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.checked) < devServerProbeInterval {
+		return p.reachable
+	}
+	_, err := probeDevServer(ctx, []string{p.url}, p.timeout)
+	p.checked = time.Now()
+	p.reachable = err == nil
+	return p.reachable
+}
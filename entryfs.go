@@ -0,0 +1,218 @@
+package vite
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// entryFSDir is the directory of the virtual files of an [EntryFS].
+const entryFSDir = "entry"
+
+// EntryFS is a read-only file system of virtual files derived from a
+// manifest, for integrations that consume the build through plain file
+// APIs, e.g. edge workers or embedded webviews. For each entry point, by
+// its name, it has:
+//
+//   - entry/main.css, the stylesheets of the entry point and its imports,
+//     concatenated.
+//   - entry/main.preloads.json, the URLs of the modules to preload for the
+//     entry point, as a JSON array.
+//
+// Files are generated when they are opened. Serve them with [http.FileServerFS].
+type EntryFS struct {
+	m       Manifest
+	fsys    fs.FS
+	base    string
+	entries map[string]string // entry name -> manifest key
+	names   []string          // sorted entry names
+}
+
+// NewEntryFS returns the virtual files of the entry points of m. fsys is
+// the Vite output directory the stylesheets are read from, and base the
+// base of the preload URLs, e.g. "/".
+func NewEntryFS(m *Manifest, fsys fs.FS, base string) *EntryFS {
+	e := &EntryFS{
+		m:       *m,
+		fsys:    fsys,
+		base:    normalizeBase(base),
+		entries: make(map[string]string),
+	}
+	for _, key := range m.keys() {
+		chunk := (*m)[key]
+		if !chunk.isPageEntry() {
+			continue
+		}
+		name := chunk.Name
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(chunk.Src), path.Ext(chunk.Src))
+		}
+		if _, ok := e.entries[name]; name == "" || strings.Contains(name, "/") || ok {
+			continue
+		}
+		e.entries[name] = key
+		e.names = append(e.names, name)
+	}
+	sort.Strings(e.names)
+	return e
+}
+
+// Open opens the named virtual file.
+func (e *EntryFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	switch name {
+	case ".":
+		return &entryDir{info: entryFileInfo{name: ".", dir: true}, entries: []fs.DirEntry{
+			entryDirEntry{fsys: e, path: entryFSDir},
+		}}, nil
+	case entryFSDir:
+		entries := make([]fs.DirEntry, 0, 2*len(e.names))
+		for _, n := range e.names {
+			entries = append(entries,
+				entryDirEntry{fsys: e, path: entryFSDir + "/" + n + ".css"},
+				entryDirEntry{fsys: e, path: entryFSDir + "/" + n + ".preloads.json"},
+			)
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		return &entryDir{info: entryFileInfo{name: entryFSDir, dir: true}, entries: entries}, nil
+	}
+
+	data, err := e.generate(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &entryFile{
+		info:   entryFileInfo{name: path.Base(name), size: int64(len(data))},
+		Reader: bytes.NewReader(data),
+	}, nil
+}
+
+// generate returns the content of the named virtual file.
+func (e *EntryFS) generate(name string) ([]byte, error) {
+	dir, file := path.Split(name)
+	if dir != entryFSDir+"/" {
+		return nil, fs.ErrNotExist
+	}
+	if entry, ok := strings.CutSuffix(file, ".preloads.json"); ok {
+		key, ok := e.entries[entry]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		urls := []string{}
+		e.m.walkPreloads(PreloadOptions{}, []string{key}, func(file string, _ int) {
+			urls = append(urls, e.base+file)
+		})
+		return json.Marshal(urls)
+	}
+	if entry, ok := strings.CutSuffix(file, ".css"); ok {
+		key, ok := e.entries[entry]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		files, err := e.m.cssURLs("", key)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		for _, file := range files {
+			data, err := fs.ReadFile(e.fsys, file)
+			if err != nil {
+				return nil, fmt.Errorf("vite: read stylesheet: %w", err)
+			}
+			buf.Write(data)
+			if len(data) > 0 && data[len(data)-1] != '\n' {
+				buf.WriteByte('\n')
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fs.ErrNotExist
+}
+
+// entryFileInfo is the file info of a virtual file or directory.
+type entryFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (fi entryFileInfo) Name() string       { return fi.name }
+func (fi entryFileInfo) Size() int64        { return fi.size }
+func (fi entryFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi entryFileInfo) IsDir() bool        { return fi.dir }
+func (fi entryFileInfo) Sys() any           { return nil }
+
+func (fi entryFileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// entryFile is an open virtual file.
+type entryFile struct {
+	*bytes.Reader
+	info entryFileInfo
+}
+
+func (f *entryFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *entryFile) Close() error               { return nil }
+
+// entryDirEntry is an entry of a virtual directory. The file is generated
+// when its info is requested.
+type entryDirEntry struct {
+	fsys *EntryFS
+	path string
+}
+
+func (d entryDirEntry) Name() string { return path.Base(d.path) }
+func (d entryDirEntry) IsDir() bool  { return d.path == entryFSDir }
+
+func (d entryDirEntry) Type() fs.FileMode {
+	if d.IsDir() {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (d entryDirEntry) Info() (fs.FileInfo, error) {
+	return fs.Stat(d.fsys, d.path)
+}
+
+// entryDir is an open virtual directory.
+type entryDir struct {
+	info    entryFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *entryDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *entryDir) Close() error               { return nil }
+
+func (d *entryDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *entryDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,49 @@
+package vite_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/olivere/vite"
+)
+
+func TestEntryFS(t *testing.T) {
+	m, err := vite.ParseManifest(strings.NewReader(`{
+		"src/main.tsx": {"file": "assets/main-4f2e1a.js", "name": "main", "src": "src/main.tsx", "isEntry": true, "imports": ["_shared.js"], "css": ["assets/main-9b8c7d.css"]},
+		"src/admin.tsx": {"file": "assets/admin-1a2b3c.js", "name": "admin", "src": "src/admin.tsx", "isEntry": true},
+		"_shared.js": {"file": "assets/shared-5d6e7f.js", "css": ["assets/shared-0a1b2c.css"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dist := fstest.MapFS{
+		"assets/main-9b8c7d.css":   &fstest.MapFile{Data: []byte("main{}")},
+		"assets/shared-0a1b2c.css": &fstest.MapFile{Data: []byte("shared{}\n")},
+	}
+	fsys := vite.NewEntryFS(m, dist, "/app")
+
+	if err := fstest.TestFS(fsys, "entry/main.css", "entry/main.preloads.json", "entry/admin.css", "entry/admin.preloads.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(fsys, "entry/main.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "main{}\nshared{}\n", string(data); want != have {
+		t.Errorf("expected concatenated CSS %q, got %q", want, have)
+	}
+	data, err = fs.ReadFile(fsys, "entry/main.preloads.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `["/app/assets/main-4f2e1a.js","/app/assets/shared-5d6e7f.js"]`, string(data); want != have {
+		t.Errorf("expected preloads %s, got %s", want, have)
+	}
+	if _, err := fs.ReadFile(fsys, "entry/missing.css"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package vite
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by [ConfigFromEnv] and [Config.FromEnv].
+const (
+	EnvDev       = "VITE_DEV"        // IsDev, e.g. "true" or "1"
+	EnvURL       = "VITE_URL"        // ViteURL, e.g. "http://localhost:5173"
+	EnvEntry     = "VITE_ENTRY"      // ViteEntry, e.g. "src/main.tsx"
+	EnvManifest  = "VITE_MANIFEST"   // ViteManifest, e.g. ".vite/manifest.json"
+	EnvTemplate  = "VITE_TEMPLATE"   // ViteTemplate, e.g. "react-ts"
+	EnvDir       = "VITE_DIR"        // FS, a directory on disk
+	EnvPublicDir = "VITE_PUBLIC_DIR" // PublicFS, a directory on disk
+)
+
+// ConfigFromEnv returns a configuration read from the environment. See
+// [Config.FromEnv] for the variables it reads.
+func ConfigFromEnv() (Config, error) {
+	return Config{}.FromEnv()
+}
+
+// FromEnv returns a copy of c, with the fields overridden by the
+// environment variables that are set and not empty:
+//
+//   - VITE_DEV sets IsDev; it accepts the values of [strconv.ParseBool].
+//   - VITE_URL sets ViteURL.
+//   - VITE_ENTRY sets ViteEntry.
+//   - VITE_MANIFEST sets ViteManifest.
+//   - VITE_TEMPLATE sets ViteTemplate; see [ParseScaffolding].
+//   - VITE_DIR sets FS to the directory with that path.
+//   - VITE_PUBLIC_DIR sets PublicFS to the directory with that path.
+//
+// This allows deployments to switch between development and production
+// mode, or to point to another dev server, without code changes:
+//
+//	config, err := vite.Config{FS: os.DirFS("dist")}.FromEnv()
+func (c Config) FromEnv() (Config, error) {
+	if v := os.Getenv(EnvDev); v != "" {
+		isDev, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, fmt.Errorf("vite: invalid %s: %w", EnvDev, err)
+		}
+		c.IsDev = isDev
+	}
+	if v := os.Getenv(EnvURL); v != "" {
+		c.ViteURL = v
+	}
+	if v := os.Getenv(EnvEntry); v != "" {
+		c.ViteEntry = v
+	}
+	if v := os.Getenv(EnvManifest); v != "" {
+		c.ViteManifest = v
+	}
+	if v := os.Getenv(EnvTemplate); v != "" {
+		s, err := ParseScaffolding(v)
+		if err != nil {
+			return c, fmt.Errorf("vite: invalid %s: %w", EnvTemplate, err)
+		}
+		c.ViteTemplate = s
+	}
+	if v := os.Getenv(EnvDir); v != "" {
+		c.FS = os.DirFS(v)
+	}
+	if v := os.Getenv(EnvPublicDir); v != "" {
+		c.PublicFS = os.DirFS(v)
+	}
+	return c, nil
+}
+
+// scaffoldingNames maps the template names of create-vite to scaffoldings.
+var scaffoldingNames = map[string]Scaffolding{
+	"react":        React,
+	"react-ts":     ReactTs,
+	"react-swc":    ReactSwc,
+	"react-swc-ts": ReactSwcTs,
+	"vanilla":      Vanilla,
+	"vanilla-ts":   VanillaTs,
+	"vue":          Vue,
+	"vue-ts":       VueTs,
+	"preact":       Preact,
+	"preact-ts":    PreactTs,
+	"lit":          Lit,
+	"lit-ts":       LitTs,
+	"svelte":       Svelte,
+	"svelte-ts":    SvelteTs,
+	"solid":        Solid,
+	"solid-ts":     SolidTs,
+	"qwik":         Qwik,
+	"qwik-ts":      QwikTs,
+	"none":         None,
+}
+
+// ParseScaffolding returns the scaffolding for the name of a create-vite
+// template, e.g. "react-ts" or "vue". Use "none" to opt out of a specific
+// scaffolding. Names are case-insensitive.
+func ParseScaffolding(name string) (Scaffolding, error) {
+	if s, ok := scaffoldingNames[strings.ToLower(name)]; ok {
+		return s, nil
+	}
+	return 0, fmt.Errorf("vite: unknown scaffolding %q", name)
+}
+
+// String returns the name of the create-vite template of the scaffolding,
+// e.g. "react-swc-ts", as accepted by [ParseScaffolding]. It returns a
+// placeholder like "Scaffolding(0)" for an unset or unknown scaffolding.
+func (s Scaffolding) String() string {
+	for name, v := range scaffoldingNames {
+		if v == s {
+			return name
+		}
+	}
+	return "Scaffolding(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText implements [encoding.TextMarshaler], so that the scaffolding
+// is written by name, e.g. to JSON configuration files. An unset
+// scaffolding is written as an empty string.
+func (s Scaffolding) MarshalText() ([]byte, error) {
+	if s == 0 {
+		return []byte{}, nil
+	}
+	for name, v := range scaffoldingNames {
+		if v == s {
+			return []byte(name), nil
+		}
+	}
+	return nil, fmt.Errorf("vite: unknown scaffolding %d", int(s))
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler], so that the
+// scaffolding is read by name, e.g. "react-ts", see [ParseScaffolding]. An
+// empty string leaves the scaffolding unset.
+func (s *Scaffolding) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = 0
+		return nil
+	}
+	v, err := ParseScaffolding(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
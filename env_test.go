@@ -0,0 +1,96 @@
+package vite_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/olivere/vite"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("VITE_DEV", "true")
+	t.Setenv("VITE_URL", "http://vite.internal:5173")
+	t.Setenv("VITE_ENTRY", "src/admin.tsx")
+	t.Setenv("VITE_TEMPLATE", "react-ts")
+	t.Setenv("VITE_DIR", t.TempDir())
+
+	base := vite.Config{
+		ViteURL:      "http://localhost:5173",
+		ViteManifest: "manifest.json",
+	}
+	config, err := base.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.IsDev {
+		t.Error("expected IsDev to be true")
+	}
+	if want, have := "http://vite.internal:5173", config.ViteURL; want != have {
+		t.Errorf("expected ViteURL %q, got %q", want, have)
+	}
+	if want, have := "src/admin.tsx", config.ViteEntry; want != have {
+		t.Errorf("expected ViteEntry %q, got %q", want, have)
+	}
+	if want, have := "manifest.json", config.ViteManifest; want != have {
+		t.Errorf("expected ViteManifest %q, got %q", want, have)
+	}
+	if want, have := vite.ReactTs, config.ViteTemplate; want != have {
+		t.Errorf("expected ViteTemplate %v, got %v", want, have)
+	}
+	if config.FS == nil {
+		t.Error("expected FS to be set")
+	}
+	if base.IsDev {
+		t.Error("expected the base config to be unchanged")
+	}
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"VITE_DEV":      "maybe",
+		"VITE_TEMPLATE": "angular",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := vite.ConfigFromEnv(); err == nil {
+				t.Fatalf("expected an error for %s=%q", name, value)
+			}
+		})
+	}
+}
+
+func TestScaffoldingNames(t *testing.T) {
+	s, err := vite.ParseScaffolding("React-SWC-TS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != vite.ReactSwcTs {
+		t.Fatalf("expected ReactSwcTs, got %v", s)
+	}
+	if want, have := "react-swc-ts", s.String(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+	if want, have := "Scaffolding(0)", vite.Scaffolding(0).String(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+
+	var config struct {
+		Template vite.Scaffolding `json:"template"`
+	}
+	if err := json.Unmarshal([]byte(`{"template": "vue-ts"}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Template != vite.VueTs {
+		t.Errorf("expected VueTs, got %v", config.Template)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `{"template":"vue-ts"}`, string(data); want != have {
+		t.Errorf("expected %s, got %s", want, have)
+	}
+	if err := json.Unmarshal([]byte(`{"template": "angular"}`), &config); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+}
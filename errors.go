@@ -0,0 +1,30 @@
+package vite
+
+import "errors"
+
+// The errors below describe the failure modes of the handler, so that
+// applications can branch on them with [errors.Is] instead of matching
+// error messages. They are always wrapped together with the underlying
+// error, if any.
+var (
+	// ErrManifestNotFound indicates that the Vite manifest does not exist,
+	// e.g. because "vite build" has not been run yet.
+	ErrManifestNotFound = errors.New("manifest not found")
+
+	// ErrManifestInvalid indicates that the Vite manifest cannot be parsed.
+	ErrManifestInvalid = errors.New("invalid manifest")
+
+	// ErrTemplateNotFound indicates that no template was found, e.g. when
+	// the patterns passed to [Handler.RegisterTemplatesFS] match no files.
+	// A [TemplateEngine] may return it from Execute.
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrEntryNotFound indicates that an entry point is not in the manifest.
+	ErrEntryNotFound = errors.New("entry point not found")
+
+	// ErrChunkNotFound indicates that a chunk is not in the manifest.
+	ErrChunkNotFound = errors.New("chunk not found")
+
+	// ErrViteUnreachable indicates that the Vite dev server does not respond.
+	ErrViteUnreachable = errors.New("vite dev server unreachable")
+)
@@ -0,0 +1,126 @@
+package vite
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ManifestLoadedEvent is published when the handler loads a new version
+// of the manifest, see [Handler.OnManifestLoaded].
+type ManifestLoadedEvent struct {
+	// Path is the path of the manifest in the file system.
+	Path string
+	// Manifest is the new manifest.
+	Manifest *Manifest
+}
+
+// RenderEvent is published after the handler rendered a page, see
+// [Handler.OnRender].
+type RenderEvent struct {
+	// Request is the request of the page.
+	Request *http.Request
+	// Path is the path of the page, relative to the base.
+	Path string
+	// Template is the name of the template the page was looked up with.
+	Template string
+	// Entry is the primary entry point of the page.
+	Entry string
+	// Duration is the time it took to render the page.
+	Duration time.Duration
+}
+
+// AssetServedEvent is published after the handler served a file, see
+// [Handler.OnAssetServed].
+type AssetServedEvent struct {
+	// Request is the request of the file.
+	Request *http.Request
+	// Path is the path of the file, relative to the base.
+	Path string
+	// Public is true if the file was served from the public directory in
+	// development mode.
+	Public bool
+}
+
+// handlerEvents holds the subscribers to the events of a handler and its
+// groups and host views.
+type handlerEvents struct {
+	manifestLoaded subscribers[ManifestLoadedEvent]
+	render         subscribers[RenderEvent]
+	assetServed    subscribers[AssetServedEvent]
+}
+
+// subscribers are the functions subscribed to an event, in the order of
+// subscription.
+type subscribers[E any] struct {
+	mu   sync.RWMutex
+	next int
+	subs []subscriber[E]
+}
+
+type subscriber[E any] struct {
+	id int
+	fn func(E)
+}
+
+// subscribe adds fn and returns a function that removes it again.
+func (s *subscribers[E]) subscribe(fn func(E)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	id := s.next
+	s.subs = append(s.subs, subscriber[E]{id: id, fn: fn})
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, sub := range s.subs {
+			if sub.id == id {
+				s.subs = append(s.subs[:i:i], s.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// publish calls the subscribers with e.
+func (s *subscribers[E]) publish(e E) {
+	s.mu.RLock()
+	subs := s.subs
+	s.mu.RUnlock()
+	for _, sub := range subs {
+		sub.fn(e)
+	}
+}
+
+// OnManifestLoaded subscribes fn to new versions of the manifest, loaded
+// with [Handler.ReloadManifest] or [Handler.WatchManifest], e.g. to warm
+// caches after a deploy. It returns a function that cancels the
+// subscription.
+//
+// Subscribers of all events are called synchronously, in the order of
+// subscription, and are shared by the groups and host views of the
+// handler. Start a goroutine for slow work.
+func (h *Handler) OnManifestLoaded(fn func(ManifestLoadedEvent)) (cancel func()) {
+	if h.parent != nil {
+		return h.parent.OnManifestLoaded(fn)
+	}
+	return h.events.manifestLoaded.subscribe(fn)
+}
+
+// OnRender subscribes fn to pages rendered by the handler, e.g. for
+// logging or audits. It returns a function that cancels the subscription.
+func (h *Handler) OnRender(fn func(RenderEvent)) (cancel func()) {
+	if h.parent != nil {
+		return h.parent.OnRender(fn)
+	}
+	return h.events.render.subscribe(fn)
+}
+
+// OnAssetServed subscribes fn to files served by the handler. It returns
+// a function that cancels the subscription.
+func (h *Handler) OnAssetServed(fn func(AssetServedEvent)) (cancel func()) {
+	if h.parent != nil {
+		return h.parent.OnAssetServed(fn)
+	}
+	return h.events.assetServed.subscribe(fn)
+}
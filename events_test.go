@@ -0,0 +1,61 @@
+package vite_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/olivere/vite"
+)
+
+func TestHandlerEvents(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.tsx": {"file": "assets/main-4f2e1a.js", "src": "src/main.tsx", "isEntry": true}
+		}`)},
+		"assets/main-4f2e1a.js": &fstest.MapFile{Data: []byte("console.log(1)")},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys, ViteEntry: "src/main.tsx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		manifests []vite.ManifestLoadedEvent
+		renders   []vite.RenderEvent
+		assets    []vite.AssetServedEvent
+	)
+	h.OnManifestLoaded(func(e vite.ManifestLoadedEvent) { manifests = append(manifests, e) })
+	cancel := h.OnRender(func(e vite.RenderEvent) { renders = append(renders, e) })
+	h.OnAssetServed(func(e vite.AssetServedEvent) { assets = append(assets, e) })
+
+	if err := h.ReloadManifest(); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 || manifests[0].Path != ".vite/manifest.json" || manifests[0].Manifest == nil {
+		t.Errorf("expected a manifest loaded event, got %+v", manifests)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(renders) != 1 {
+		t.Fatalf("expected a render event, got %+v", renders)
+	}
+	if want, have := "index.html", renders[0].Template; want != have {
+		t.Errorf("expected template %q, got %q", want, have)
+	}
+	if want, have := "src/main.tsx", renders[0].Entry; want != have {
+		t.Errorf("expected entry %q, got %q", want, have)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/assets/main-4f2e1a.js", nil))
+	if len(assets) != 1 || assets[0].Path != "/assets/main-4f2e1a.js" || assets[0].Public {
+		t.Errorf("expected an asset served event, got %+v", assets)
+	}
+
+	cancel()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(renders) != 1 {
+		t.Errorf("expected no render event after canceling, got %+v", renders)
+	}
+}
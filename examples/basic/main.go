@@ -3,7 +3,6 @@ package main
 import (
 	"embed"
 	"flag"
-	"fmt"
 	"io/fs"
 	"log"
 	"net"
@@ -32,9 +31,10 @@ func main() {
 func runDevServer() {
 	// Handle the Vite server.
 	viteHandler, err := vite.NewHandler(vite.Config{
-		FS:      os.DirFS("."),
-		IsDev:   true,
-		ViteURL: "http://localhost:5173",
+		FS:           os.DirFS("."),
+		IsDev:        true,
+		ViteURL:      "http://localhost:5173",
+		ViteTemplate: vite.React,
 	})
 	if err != nil {
 		panic(err)
@@ -56,24 +56,11 @@ func runDevServer() {
 		viteHandler.ServeHTTP(w, r)
 	})
 
-	// Start a listener.
-	l, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		var err1 error
-		if l, err1 = net.Listen("tcp6", "[::1]:0"); err1 != nil {
-			panic(fmt.Errorf("starting HTTP server: %v", err))
-		}
-	}
-
-	// Create a new server.
-	server := http.Server{
-		Handler: handler,
+	// Start the server on a random port of the loopback interface.
+	onListen := func(addr net.Addr) {
+		log.Printf("Listening on on http://%s", addr)
 	}
-
-	log.Printf("Listening on on http://%s", l.Addr())
-
-	// Start the server.
-	if err := server.Serve(l); err != nil {
+	if err := vite.ListenAndServe("localhost:0", handler, vite.WithOnListen(onListen)); err != nil {
 		panic(err)
 	}
 }
@@ -109,24 +96,11 @@ func runProdServer() {
 		viteHandler.ServeHTTP(w, r)
 	})
 
-	// Start a listener.
-	l, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		var err1 error
-		if l, err1 = net.Listen("tcp6", "[::1]:0"); err1 != nil {
-			panic(fmt.Errorf("starting HTTP server: %v", err))
-		}
-	}
-
-	// Create a new server.
-	server := http.Server{
-		Handler: handler,
+	// Start the server on a random port of the loopback interface.
+	onListen := func(addr net.Addr) {
+		log.Printf("Listening on on http://%s", addr)
 	}
-
-	log.Printf("Listening on on http://%s", l.Addr())
-
-	// Start the server.
-	if err := server.Serve(l); err != nil {
+	if err := vite.ListenAndServe("localhost:0", handler, vite.WithOnListen(onListen)); err != nil {
 		panic(err)
 	}
 }
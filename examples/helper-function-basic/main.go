@@ -44,9 +44,10 @@ func runDevServer() {
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" || r.URL.Path == "/index.html" {
 			viteFragment, err := vite.HTMLFragment(vite.Config{
-				FS:      os.DirFS("."),
-				IsDev:   true,
-				ViteURL: "http://localhost:5173",
+				FS:           os.DirFS("."),
+				IsDev:        true,
+				ViteURL:      "http://localhost:5173",
+				ViteTemplate: vite.React,
 			})
 			if err != nil {
 				http.Error(w, "Error instantiating vite fragment", http.StatusInternalServerError)
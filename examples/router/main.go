@@ -102,21 +102,22 @@ func FrontendHandler(isDev bool, appFS, publicFS fs.FS, paths ...string) http.Ha
 		viteConfig.ViteURL = "http://localhost:5173"
 	}
 
+	// Render the fragment once instead of on every request.
+	fragments, err := vite.NewFragmentCache(viteConfig)
+	if err != nil {
+		log.Fatalf("creating vite fragment cache: %v", err)
+	}
+	tmpl := template.Must(template.New("index").Parse(indexTmpl))
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		for _, path := range paths {
 			if r.URL.Path == path {
-				viteFragment, err := vite.HTMLFragment(viteConfig)
+				viteFragment, err := fragments.Fragment(vite.FragmentOptions{})
 				if err != nil {
 					http.Error(w, "Error instantiating vite fragment", http.StatusInternalServerError)
 					return
 				}
 
-				tmpl, err := template.New("index").Parse(indexTmpl)
-				if err != nil {
-					http.Error(w, "Error parsing template", http.StatusInternalServerError)
-					return
-				}
-
 				if err = tmpl.Execute(w, map[string]interface{}{
 					"Title":   "Homepage",
 					"Vite":    viteFragment,
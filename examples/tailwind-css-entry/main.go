@@ -0,0 +1,86 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/olivere/vite"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// indexTmpl renders the page. Tailwind scans this file for classes, see
+// tailwind.config.ts.
+var indexTmpl = `<!doctype html>
+<html lang="en">
+  <head>
+    <meta charset="UTF-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+    <title>Tailwind CSS entry</title>
+    {{- if .IsDev }}
+    {{ .StyleSheets }}
+    <script type="module" src="{{ .ViteURL }}/@vite/client"></script>
+    {{- range .ViteEntries }}
+    <script type="module" src="{{ $.ViteURL }}/{{ . }}"></script>
+    {{- end }}
+    {{- else }}
+    {{ .StyleSheets }}
+    {{ .Modules }}
+    {{ .PreloadModules }}
+    {{- end }}
+  </head>
+  <body class="min-h-screen bg-slate-50 text-slate-900 antialiased">
+    <main class="mx-auto max-w-xl p-8">
+      <h1 class="text-3xl font-bold tracking-tight">Hello from Go and Tailwind</h1>
+      <p class="mt-2 text-slate-600">
+        Edit the classes in main.go: Vite updates the stylesheet without a reload.
+      </p>
+      <button id="counter" class="mt-6 rounded bg-indigo-600 px-4 py-2 font-medium text-white hover:bg-indigo-500">
+        Click me
+      </button>
+    </main>
+  </body>
+</html>
+`
+
+func main() {
+	isDev := flag.Bool("dev", false, "run in development mode")
+	flag.Parse()
+
+	var appFS fs.FS
+	if *isDev {
+		appFS = os.DirFS(".")
+	} else {
+		distFS, err := fs.Sub(dist, "dist")
+		if err != nil {
+			log.Fatalf("creating sub-filesystem for 'dist' directory: %v", err)
+		}
+		appFS = distFS
+	}
+
+	// The stylesheet is an entry point of its own. The handler links it
+	// from the Vite dev server in development mode, and from the manifest
+	// in production mode.
+	h, err := vite.NewHandler(vite.Config{
+		FS:           appFS,
+		IsDev:        *isDev,
+		ViteURL:      "http://localhost:5173",
+		ViteEntries:  []string{"src/main.ts", "src/styles.css"},
+		ViteTemplate: vite.None,
+	})
+	if err != nil {
+		log.Fatalf("creating vite handler: %v", err)
+	}
+	h.RegisterTemplate("index.html", indexTmpl)
+
+	log.Print("Open browser at http://localhost:8080")
+
+	if err := http.ListenAndServe(":8080", h); err != nil {
+		log.Fatal(err)
+	}
+}
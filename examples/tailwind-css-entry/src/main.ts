@@ -0,0 +1,6 @@
+const button = document.querySelector<HTMLButtonElement>('#counter')!
+let count = 0
+button.addEventListener('click', () => {
+  count++
+  button.textContent = `Clicked ${count} times`
+})
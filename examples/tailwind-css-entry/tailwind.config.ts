@@ -0,0 +1,14 @@
+import type { Config } from 'tailwindcss'
+
+export default {
+  content: [
+    // The page is rendered by the Go template in main.go, so Tailwind
+    // has to scan it for classes, too.
+    "./main.go",
+    "./src/**/*.ts",
+  ],
+  theme: {
+    extend: {},
+  },
+  plugins: [],
+} satisfies Config
@@ -0,0 +1,14 @@
+import { defineConfig } from 'vite'
+
+// https://vitejs.dev/config/
+export default defineConfig({
+  build: {
+    // generates .vite/manifest.json in outDir
+    manifest: true,
+
+    rollupOptions: {
+      // The stylesheet is an entry point of its own, next to the script.
+      input: ["src/main.ts", "src/styles.css"],
+    },
+  },
+})
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"io"
 	"net/url"
+	"strings"
+	"sync"
 )
 
 // Fragment holds HTML content generated for Vite integration, intended to be
@@ -14,6 +17,31 @@ type Fragment struct {
 	// such as JavaScript and CSS. The content is stored as template.HTML to
 	// ensure it is rendered without escaping within the HTML template.
 	Tags template.HTML
+
+	// Preamble is the preamble for React Fast Refresh, in development mode.
+	Preamble template.HTML
+
+	// StyleSheets are the stylesheet links of the entry points. In
+	// development mode, they link CSS-only entry points on the dev server.
+	StyleSheets template.HTML
+
+	// Modules are the module scripts of the entry points. In development
+	// mode, they load the Vite client and the entry points from the dev
+	// server.
+	Modules template.HTML
+
+	// PreloadModules are the modulepreload links of the entry points, in
+	// production mode.
+	PreloadModules template.HTML
+
+	// BodyTags are the tags to render before </body>, i.e. the module
+	// scripts if ModulePlacement is PlaceAtBodyEnd. Tags does not include
+	// them then.
+	BodyTags template.HTML
+
+	// Mounts are the elements the entry points mount into, with ids
+	// derived from the entry names, see [MountID].
+	Mounts []Mount
 }
 
 // HTMLFragment generates an HTML fragment for Vite integration based on the provided configuration.
@@ -43,89 +71,194 @@ type Fragment struct {
 //	}
 //	// Use fragment in your HTML template
 func HTMLFragment(config Config) (*Fragment, error) {
-	pd := &pageData{
-		IsDev:     config.IsDev,
-		ViteEntry: config.ViteEntry,
-		ViteURL:   config.ViteURL,
-	}
+	return HTMLFragmentWithOptions(config, FragmentOptions{})
+}
 
-	if config.IsDev {
-		// Check if the specified Vite template requires a preamble and set the
-		// corresponding preamble string in the plugin configuration.
-		//
-		// If the Vite template value is less than 1, it is considered as an
-		// uninitialized state, and the default React preamble is applied.
-		// Otherwise, if the template requires a preamble, it uses the
-		// specific preamble for the given Vite template.
-		if config.ViteTemplate < 1 {
-			pd.PluginReactPreamble = template.HTML(React.Preamble(config.ViteURL))
-		} else if config.ViteTemplate.RequiresPreamble() {
-			pd.PluginReactPreamble = template.HTML(config.ViteTemplate.Preamble(config.ViteURL))
-		}
+// HTMLFragments is like [HTMLFragment] for several entry points, e.g. an
+// app and a bundle of web components mounted on the same page. Stylesheets
+// and preloads shared by the entry points are included once. It is a
+// shorthand for setting ViteEntries, or Entries of [FragmentOptions].
+func HTMLFragments(config Config, entries ...string) (*Fragment, error) {
+	return HTMLFragmentWithOptions(config, FragmentOptions{Entries: entries})
+}
 
-		// Development mode.
-		if pd.ViteURL == "" {
-			pd.ViteURL = "http://localhost:5173"
-		}
-	} else {
-		if config.ViteManifest == "" {
-			config.ViteManifest = ".vite/manifest.json"
-		}
-		mf, err := config.FS.Open(config.ViteManifest)
-		if err != nil {
-			return nil, fmt.Errorf("vite: open manifest: %w", err)
-		}
-		defer mf.Close()
+// FragmentOptions are the inputs of a fragment besides the configuration,
+// see [HTMLFragmentWithOptions].
+type FragmentOptions struct {
+	// Metadata is the metadata of the page, rendered at the start of the
+	// fragment.
+	Metadata *Metadata
 
-		m, err := ParseManifest(mf)
-		if err != nil {
-			return nil, fmt.Errorf("vite: parse manifest: %w", err)
-		}
-		var chunk *Chunk
-		if pd.ViteEntry == "" {
-			chunk = m.GetEntryPoint()
-		} else {
-			entries := m.GetEntryPoints()
-			for _, entry := range entries {
-				if pd.ViteEntry == entry.Src {
-					chunk = entry
-					break
-				}
-			}
-		}
-		if chunk == nil {
-			return nil, fmt.Errorf("vite: unable to find chunk for entry point %q", pd.ViteEntry)
-		}
+	// Scripts are rendered at the end of the fragment.
+	Scripts string
 
-		pd.StyleSheets = template.HTML(m.GenerateCSS(chunk.Src))
-		pd.Modules = template.HTML(m.GenerateModules(chunk.Src))
-		pd.PreloadModules = template.HTML(m.GeneratePreloadModules(chunk.Src))
-	}
+	// Entries overrides the entry points of the configuration.
+	Entries []string
+}
 
+// HTMLFragmentWithOptions is like [HTMLFragment], and also renders the
+// metadata and scripts of opts, so that standalone templates get the same
+// head as pages rendered by the handler:
+//
+//	fragment, err := vite.HTMLFragmentWithOptions(config, vite.FragmentOptions{
+//		Metadata: &vite.Metadata{Title: "Welcome"},
+//		Scripts:  `<script>window.env = "production"</script>`,
+//	})
+func HTMLFragmentWithOptions(config Config, opts FragmentOptions) (*Fragment, error) {
 	// Create a buffer to store the executed template output
 	var buf bytes.Buffer
+	pd, err := writeFragment(&buf, config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fragment := &Fragment{
+		Tags:           template.HTML(buf.Bytes()),
+		Preamble:       pd.PluginReactPreamble,
+		StyleSheets:    pd.StyleSheets,
+		Modules:        pd.Modules,
+		PreloadModules: pd.PreloadModules,
+		BodyTags:       pd.BodyModules,
+		Mounts:         pd.Mounts,
+	}
+	if pd.IsDev {
+		fragment.Modules = devModules(pd, config.ScriptAttributes)
+	} else if pd.BodyModules != "" {
+		fragment.Modules = pd.BodyModules
+	}
+	return fragment, nil
+}
+
+// WriteHTMLFragment writes the tags of [HTMLFragment] to w, e.g. the
+// response or the writer of a streaming template engine, without building
+// the fragment in memory first.
+func WriteHTMLFragment(w io.Writer, config Config) error {
+	_, err := writeFragment(w, config, FragmentOptions{})
+	return err
+}
+
+// writeFragment writes the tags of the fragment for the given configuration
+// and options to w, and returns the page data it was rendered with.
+func writeFragment(w io.Writer, config Config, opts FragmentOptions) (*PageData, error) {
+	if len(opts.Entries) > 0 {
+		config.ViteEntry = opts.Entries[0]
+		config.ViteEntries = opts.Entries
+	}
+	pd, err := fragmentPageData(config)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Metadata != nil {
+		pd.Metadata = template.HTML(opts.Metadata.String())
+	}
+	pd.Scripts = template.HTML(opts.Scripts)
 
 	// Pass the JoinPath function to the template so we
-	// can use {{ urljoin .base .path }}
+	// can use {{ urljoin .base .path }}, and the attributes
+	// of the module scripts of the entry points.
 	templateFuncs := template.FuncMap{
 		"urljoin": url.JoinPath,
+		"scriptAttrs": func(entry string) template.HTMLAttr {
+			return config.ScriptAttributes[strings.TrimPrefix(entry, "/")].html()
+		},
+		"mountID": MountID,
 	}
 
-	// Parse the predefined headTmpl into a new template
-	tmpl, err := template.New("vite").Funcs(templateFuncs).Parse(htmlTmpl)
+	// Parse the predefined htmlTmpl, or the custom template of the
+	// configuration, into a new template
+	text := htmlTmpl
+	if config.FragmentTemplate != "" {
+		text = config.FragmentTemplate
+	}
+	tmpl, err := template.New("vite").Funcs(templateFuncs).Parse(text)
 	if err != nil {
 		// Return an error if parsing fails
 		return nil, fmt.Errorf("vite: parse template: %w", err)
 	}
 
 	// Execute the template with pd (PageData) as the data source
-	err = tmpl.Execute(&buf, pd)
+	err = tmpl.Execute(w, pd)
 	if err != nil {
 		// Return an error if template execution fails
 		return nil, fmt.Errorf("vite: execute template: %w", err)
 	}
+	return pd, nil
+}
+
+// fragmentPageData returns the page data for the fragment of the given
+// configuration.
+func fragmentPageData(config Config) (*PageData, error) {
+	pd := &PageData{
+		IsDev:       config.IsDev,
+		ViteEntry:   config.ViteEntry,
+		ViteEntries: config.ViteEntries,
+		ViteURL:     config.ViteURL,
+		NoScript:    config.NoScriptHTML,
+	}
 
-	return &Fragment{Tags: template.HTML(buf.Bytes())}, nil
+	base := normalizeBase(config.Base)
+
+	if config.IsDev {
+		// Development mode.
+		if pd.ViteURL == "" {
+			pd.ViteURL = "http://localhost:5173"
+		}
+		pd.ViteURL = devServerURL(pd.ViteURL, base)
+		if pd.ViteEntry == "" && len(pd.ViteEntries) == 0 {
+			pd.ViteEntry = config.defaultEntry()
+		}
+
+		// Check if the specified Vite template requires a preamble and set the
+		// corresponding preamble string in the plugin configuration. Without
+		// a Vite template, no preamble is applied.
+		if config.ViteTemplate.RequiresPreamble() {
+			pd.PluginReactPreamble = template.HTML(config.ViteTemplate.Preamble(pd.ViteURL))
+		}
+		devStyleSheets(pd)
+	} else {
+		m := config.Manifest
+		if m == nil {
+			if config.ViteManifest == "" {
+				config.ViteManifest = ".vite/manifest.json"
+			}
+			var err error
+			if m, err = readManifestFile(config.FS, config.ViteManifest); err != nil {
+				return nil, err
+			}
+		}
+		entries := pd.ViteEntries
+		if len(entries) == 0 {
+			entries = []string{pd.ViteEntry}
+		}
+		keys, err := m.lookupEntryPoints(entries)
+		if err != nil {
+			return nil, err
+		}
+
+		pd.StyleSheets = template.HTML(m.generateCSS(base, keys...))
+		var modules strings.Builder
+		for _, key := range keys {
+			urls, _ := m.moduleURLs(base, key)
+			modules.WriteString(moduleTags(urls, config.ScriptAttributes[key]))
+		}
+		pd.Modules = template.HTML(modules.String())
+		pd.PreloadModules = template.HTML(m.generatePreloadModules(base, preloadOptionsFor(config.preloadOptions(), config.PreloadPolicies, keys[0]), keys...))
+		if config.FS != nil {
+			pd.Budget, _ = m.AssetBudget(config.FS, keys...)
+		}
+	}
+
+	pd.Mounts = mounts(pd)
+
+	// Move the module scripts to the body, if configured.
+	if config.ModulePlacement == PlaceAtBodyEnd {
+		if pd.IsDev {
+			pd.BodyModules = devModules(pd, config.ScriptAttributes)
+		} else {
+			pd.BodyModules, pd.Modules = pd.Modules, ""
+		}
+	}
+	return pd, nil
 }
 
 // htmlTmpl is a constant string that contains a Go template for including
@@ -133,13 +266,23 @@ func HTMLFragment(config Config) (*Fragment, error) {
 // This template adapts its output based on whether the application is running
 // in development or production mode.
 const htmlTmpl = `
+{{- if .Metadata }}
+	{{ .Metadata }}
+{{- end }}
 {{- if .IsDev }}
 	{{ .PluginReactPreamble }}
+	{{- if .StyleSheets }}
+	{{ .StyleSheets }}
+	{{- end }}
+	{{- if not .BodyModules }}
 	<script type="module" src="{{ urljoin .ViteURL "/@vite/client" }}"></script>
-	{{- if ne .ViteEntry "" }}
-		<script type="module" src="{{ urljoin .ViteURL .ViteEntry }}"></script>
-	{{- else }}
-		<script type="module" src="{{ urljoin .ViteURL "/src/main.tsx" }}"></script>
+	{{- if .ViteEntries }}
+		{{- range .ViteEntries }}
+		<script type="module" src="{{ urljoin $.ViteURL . }}"{{ scriptAttrs . }}></script>
+		{{- end }}
+	{{- else if ne .ViteEntry "" }}
+		<script type="module" src="{{ urljoin .ViteURL .ViteEntry }}"{{ scriptAttrs .ViteEntry }}></script>
+	{{- end }}
 	{{- end }}
 {{- else }}
 	{{- if .StyleSheets }}
@@ -152,4 +295,93 @@ const htmlTmpl = `
 	{{ .PreloadModules }}
 	{{- end }}
 {{- end }}
+{{- if .Scripts }}
+	{{ .Scripts }}
+{{- end }}
 `
+
+// maxFragmentCacheEntries limits the number of fragments a FragmentCache
+// keeps. The cache starts over when it is full, e.g. if every request has
+// its own metadata.
+const maxFragmentCacheEntries = 1024
+
+// FragmentCache renders fragments like [HTMLFragmentWithOptions] and keeps
+// them, so that handlers calling it per request do not read the manifest
+// and parse the template every time. Fragments are cached by the entry
+// points, metadata, and scripts of the options. It is safe for concurrent
+// use.
+//
+//	cache, err := vite.NewFragmentCache(config)
+//	if err != nil {
+//		// Handle error
+//	}
+//	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+//		fragment, err := cache.Fragment(vite.FragmentOptions{})
+//		...
+//	})
+type FragmentCache struct {
+	config Config
+
+	mu        sync.Mutex
+	fragments map[string]*Fragment
+}
+
+// NewFragmentCache creates a cache of fragments for the given
+// configuration. In production mode, it reads the manifest from FS once,
+// unless Manifest is set, and returns an error if that fails.
+func NewFragmentCache(config Config) (*FragmentCache, error) {
+	if !config.IsDev && config.Manifest == nil {
+		name := config.ViteManifest
+		if name == "" {
+			name = ".vite/manifest.json"
+		}
+		m, err := readManifestFile(config.FS, name)
+		if err != nil {
+			return nil, err
+		}
+		config.Manifest = m
+	}
+	return &FragmentCache{
+		config:    config,
+		fragments: make(map[string]*Fragment),
+	}, nil
+}
+
+// Fragment returns the fragment for the given options, rendering it on the
+// first call. Errors are not cached.
+func (c *FragmentCache) Fragment(opts FragmentOptions) (*Fragment, error) {
+	key := fragmentCacheKey(opts)
+
+	c.mu.Lock()
+	fragment, ok := c.fragments[key]
+	c.mu.Unlock()
+	if ok {
+		return fragment, nil
+	}
+
+	fragment, err := HTMLFragmentWithOptions(c.config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.fragments) >= maxFragmentCacheEntries {
+		clear(c.fragments)
+	}
+	c.fragments[key] = fragment
+	return fragment, nil
+}
+
+// fragmentCacheKey returns the key of the fragment for the given options.
+func fragmentCacheKey(opts FragmentOptions) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Join(opts.Entries, "\x00"))
+	sb.WriteByte('\x01')
+	if opts.Metadata != nil {
+		sb.WriteString(opts.Metadata.String())
+	}
+	sb.WriteByte('\x01')
+	sb.WriteString(opts.Scripts)
+	return sb.String()
+}
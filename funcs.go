@@ -0,0 +1,84 @@
+package vite
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+)
+
+// Funcs returns template functions for the Vite app of the configuration,
+// to integrate it with html/template without the handler:
+//
+//   - viteTags returns the tags of [HTMLFragment] for the given entry
+//     points, or the entry points of the configuration.
+//   - viteCSS returns the stylesheet links of the entry points.
+//   - viteJS returns the preamble, the module scripts, and the
+//     modulepreload links of the entry points.
+//   - viteAsset returns the URL of a static asset, e.g. "src/logo.png",
+//     like [Handler.AssetURL].
+//
+// For example:
+//
+//	funcs, err := vite.Funcs(config)
+//	if err != nil {
+//		// Handle error
+//	}
+//	tmpl := template.Must(template.New("index").Funcs(funcs).Parse(`<head>{{ viteTags }}</head>`))
+//
+// In production mode, it reads the manifest from FS once, unless Manifest
+// is set, and returns an error if that fails. The tags are rendered once
+// per entry points, see [FragmentCache].
+func Funcs(config Config) (template.FuncMap, error) {
+	cache, err := NewFragmentCache(config)
+	if err != nil {
+		return nil, err
+	}
+	fragment := func(entries []string) (*Fragment, error) {
+		return cache.Fragment(FragmentOptions{Entries: entries})
+	}
+
+	base := normalizeBase(config.Base)
+	viteURL := config.ViteURL
+	if viteURL == "" {
+		viteURL = "http://localhost:5173"
+	}
+	viteURL = devServerURL(viteURL, base)
+	index := newManifestIndex(cache.config.Manifest)
+
+	return template.FuncMap{
+		"viteTags": func(entries ...string) (template.HTML, error) {
+			f, err := fragment(entries)
+			if err != nil {
+				return "", err
+			}
+			return f.Tags, nil
+		},
+		"viteCSS": func(entries ...string) (template.HTML, error) {
+			f, err := fragment(entries)
+			if err != nil {
+				return "", err
+			}
+			return f.StyleSheets, nil
+		},
+		"viteJS": func(entries ...string) (template.HTML, error) {
+			f, err := fragment(entries)
+			if err != nil {
+				return "", err
+			}
+			return f.Preamble + f.Modules + f.PreloadModules, nil
+		},
+		"viteAsset": func(src string) string {
+			if config.IsDev {
+				u, err := url.JoinPath(viteURL, src)
+				if err != nil {
+					return src
+				}
+				return u
+			}
+			if u, ok := index.assetURL(src, base); ok {
+				return u
+			}
+			return base + strings.TrimPrefix(src, "/")
+		},
+	}, nil
+}
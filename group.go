@@ -0,0 +1,60 @@
+package vite
+
+import (
+	"html/template"
+	"net/http"
+	"sync"
+)
+
+// handlerGroups holds the groups of a handler, by name.
+type handlerGroups struct {
+	mu sync.Mutex
+	m  map[string]*Handler
+}
+
+// noHosts is the empty set of host views used when serving groups.
+var noHosts = &handlerHosts{}
+
+// Group returns the handler group with the given name, creating it on
+// first use. A group shares the manifest, the file servers, and the rest
+// of the configuration with h, but has its own template registry and
+// default metadata. This allows sections of a site, e.g. docs, app, and
+// blog, to use different templates without parsing the manifest again.
+//
+// A new group has no registered templates, and uses the default metadata
+// of h until it has default metadata of its own. Everything else,
+// including functions registered on h later on, is used from h. Calling
+// Group on a group returns a group of the original handler.
+func (h *Handler) Group(name string) *Handler {
+	root := h.root()
+
+	root.groups.mu.Lock()
+	defer root.groups.mu.Unlock()
+
+	if g, ok := root.groups.m[name]; ok {
+		return g
+	}
+	// A group only holds its overrides; requests are served by the handler.
+	g := &Handler{
+		parent:    root,
+		group:     true,
+		templates: make(map[string]*template.Template),
+	}
+	g.templates[fallbackTemplateName] = template.Must(template.New(fallbackTemplateName).Funcs(root.templateFuncs()).Parse(fallbackHTML))
+	root.groups.m[name] = g
+	return g
+}
+
+// serveGroup serves the request with the templates and default metadata
+// of the group g, and everything else of h. Host views of h do not apply
+// to groups.
+func (h *Handler) serveGroup(w http.ResponseWriter, r *http.Request, g *Handler) {
+	hg := *h
+	hg.templates = g.templates
+	hg.templateFiles = g.templateFiles
+	if g.defaultMetadata != nil {
+		hg.defaultMetadata = g.defaultMetadata
+	}
+	hg.hosts = noHosts
+	hg.ServeHTTP(w, r)
+}
@@ -1,30 +1,79 @@
 package vite
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Handler serves files from the Vite output directory.
 type Handler struct {
-	fs              fs.FS
-	fsFS            http.FileSystem
-	fsHandler       http.Handler
-	pub             fs.FS
-	pubFS           http.FileSystem
-	pubHandler      http.Handler
-	manifest        *Manifest
-	isDev           bool
-	viteEntry       string
-	viteURL         string
-	viteTemplate    Scaffolding
-	templates       map[string]*template.Template
-	defaultMetadata *Metadata
+	fs                   fs.FS
+	fsHandler            http.Handler
+	pub                  fs.FS
+	pubHandler           http.Handler
+	publicPrefix         string
+	pubExists            *existsCache
+	manifest             *atomic.Pointer[Manifest]
+	index                *atomic.Pointer[manifestIndex]
+	manifestPath         string
+	routes               *routeTable
+	isDev                bool
+	viteEntry            string
+	viteEntries          []string
+	viteURL              string
+	base                 string
+	viteTemplate         Scaffolding
+	ssr                  SSRRenderer
+	engine               TemplateEngine
+	bodyStream           BodyStreamFunc
+	notFound             http.Handler
+	onError              ErrorHandlerFunc
+	templates            map[string]*template.Template
+	templateFiles        map[string]templateFile
+	reloadTemplates      bool
+	funcs                template.FuncMap
+	defaultMetadata      *Metadata
+	preload              PreloadOptions
+	preloadPolicies      map[string]PreloadPolicy
+	scriptAttrs          map[string]ScriptAttributes
+	adapt                AdaptFunc
+	isBot                BotDetector
+	ssrForBotsOnly       bool
+	ssrHeaders           []string
+	ssrCookies           []string
+	chain                TemplateChainFunc
+	vitals               http.Handler
+	vitalsPath           string
+	missing              *missingAssets
+	groups               *handlerGroups
+	hosts                *handlerHosts
+	parent               *Handler // of a host view or group
+	group                bool     // a group, not a host view
+	host                 *Handler // host view serving the request
+	logger               *slog.Logger
+	metrics              *handlerMetrics
+	events               *handlerEvents
+	assets               *assetsManifest // nil if not served
+	tracer               Tracer
+	cacheControl         CacheControlFunc
+	disableResourceHints bool
+	modulesAtBodyEnd     bool
+	noScript             template.HTML
+	validateHTML         bool
+	devProbe             *devServerProbe
+	servePageJSON        bool
+	indexHTML            *indexHTMLCache // nil if not served
 }
 
 // NewHandler creates a new handler.
@@ -38,19 +87,121 @@ func NewHandler(config Config) (*Handler, error) {
 		return nil, fmt.Errorf("vite: fs is nil")
 	}
 
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	if config.IsDev && config.DevServerCheck != DevServerCheckNone {
+		if config.ViteURL == "" {
+			config.ViteURL = "http://localhost:5173"
+		}
+		var err error
+		if config, err = checkDevServer(config); err != nil {
+			return nil, err
+		}
+	}
+
 	h := &Handler{
-		fs:           config.FS,
-		fsFS:         http.FS(config.FS),
-		fsHandler:    http.FileServerFS(config.FS),
-		isDev:        config.IsDev,
-		viteEntry:    config.ViteEntry,
-		viteURL:      config.ViteURL,
-		viteTemplate: config.ViteTemplate,
-		templates:    make(map[string]*template.Template),
+		fs:                   config.FS,
+		fsHandler:            http.FileServerFS(config.FS),
+		isDev:                config.IsDev,
+		reloadTemplates:      config.IsDev && config.ReloadTemplates,
+		viteEntry:            config.ViteEntry,
+		viteEntries:          config.ViteEntries,
+		viteURL:              config.ViteURL,
+		base:                 normalizeBase(config.Base),
+		viteTemplate:         config.ViteTemplate,
+		ssr:                  config.SSR,
+		engine:               config.TemplateEngine,
+		bodyStream:           config.BodyStreamFunc,
+		notFound:             config.NotFoundHandler,
+		onError:              config.ErrorHandler,
+		logger:               config.Logger,
+		metrics:              newHandlerMetrics(),
+		events:               &handlerEvents{},
+		tracer:               config.Tracer,
+		cacheControl:         config.CacheControl,
+		disableResourceHints: config.DisableResourceHints,
+		modulesAtBodyEnd:     config.ModulePlacement == PlaceAtBodyEnd,
+		noScript:             config.NoScriptHTML,
+		validateHTML:         config.IsDev && config.ValidateHTML,
+		servePageJSON:        config.ServePageData,
+		preload:              config.preloadOptions(),
+		preloadPolicies:      config.PreloadPolicies,
+		scriptAttrs:          config.ScriptAttributes,
+		isBot:                config.BotDetector,
+		ssrForBotsOnly:       config.SSRForBotsOnly,
+		ssrCookies:           config.SSRCookies,
+		chain:                config.TemplateChain,
+		templates:            make(map[string]*template.Template),
+		manifest:             new(atomic.Pointer[Manifest]),
+		index:                new(atomic.Pointer[manifestIndex]),
+		groups:               &handlerGroups{m: make(map[string]*Handler)},
+		hosts:                &handlerHosts{m: make(map[string]*Handler)},
+	}
+
+	h.missing = &missingAssets{
+		prefix:    config.AssetsPrefix,
+		threshold: config.MissingAssetsThreshold,
+		window:    config.MissingAssetsWindow,
+		notify:    config.OnMissingAssets,
+		now:       time.Now,
+	}
+	if h.missing.prefix == "" {
+		h.missing.prefix = "/assets/"
+	}
+	if h.missing.window <= 0 {
+		h.missing.window = time.Minute
+	}
+
+	if config.ServeAssetsManifest {
+		h.assets = &assetsManifest{}
+	}
+	if config.ServeIndexHTML {
+		h.indexHTML = &indexHTMLCache{}
+	}
+
+	if config.VitalsRecorder != nil {
+		h.vitals = VitalsHandler(config.VitalsRecorder)
+		h.vitalsPath = config.VitalsPath
+		if h.vitalsPath == "" {
+			h.vitalsPath = DefaultVitalsPath
+		}
+	}
+
+	for _, name := range config.SSRHeaders {
+		h.ssrHeaders = append(h.ssrHeaders, http.CanonicalHeaderKey(name))
+	}
+
+	if config.RespectClientHints {
+		h.adapt = config.AdaptFunc
+		if h.adapt == nil {
+			h.adapt = DefaultAdaptFunc
+		}
 	}
 
 	// We register a fallback template.
-	h.templates[fallbackTemplateName] = template.Must(template.New(fallbackTemplateName).Parse(fallbackHTML))
+	h.templates[fallbackTemplateName] = template.Must(template.New(fallbackTemplateName).Funcs(h.templateFuncs()).Parse(fallbackHTML))
+
+	if config.TemplateFS != nil {
+		patterns := config.TemplatePatterns
+		if len(patterns) == 0 {
+			patterns = []string{"*.html"}
+		}
+		if err := h.RegisterTemplatesFS(config.TemplateFS, patterns...); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.ViteRoutes != "" {
+		routes, err := readRoutesFile(config.FS, config.ViteRoutes)
+		if err != nil {
+			return nil, err
+		}
+		if h.routes, err = newRouteTable(routes); err != nil {
+			return nil, err
+		}
+	}
 
 	if !h.isDev {
 		// Production mode.
@@ -58,25 +209,41 @@ func NewHandler(config Config) (*Handler, error) {
 		// We expect the output directory to contain a .vite/manifest.json file.
 		// This file contains the mapping of the original file paths to the
 		// transformed file paths.
-		if config.ViteManifest == "" {
-			config.ViteManifest = ".vite/manifest.json"
-		}
-		mf, err := h.fs.Open(config.ViteManifest)
-		if err != nil {
-			return nil, fmt.Errorf("vite: open manifest: %w", err)
-		}
-		defer mf.Close()
-
-		// Read the manifest file.
-		h.manifest, err = ParseManifest(mf)
-		if err != nil {
-			return nil, fmt.Errorf("vite: parse manifest: %w", err)
+		if config.Manifest != nil {
+			h.manifest.Store(config.Manifest)
+		} else {
+			h.manifestPath = config.ViteManifest
+			if h.manifestPath == "" {
+				h.manifestPath = ".vite/manifest.json"
+			}
+			if err := h.ReloadManifest(); err != nil {
+				return nil, err
+			}
 		}
 	} else {
 		// Development mode.
 		if h.viteURL == "" {
 			h.viteURL = "http://localhost:5173"
 		}
+		h.viteURL = devServerURL(h.viteURL, h.base)
+		if h.viteEntry == "" && len(h.viteEntries) == 0 {
+			h.viteEntry = config.defaultEntry()
+		}
+		if config.ProbeDevServer {
+			timeout := config.DevServerTimeout
+			if timeout <= 0 {
+				timeout = time.Second
+			}
+			h.devProbe = &devServerProbe{url: h.viteURL, timeout: timeout}
+		}
+
+		// The React preamble used to be injected by default, which React
+		// apps may still rely on.
+		if h.viteTemplate == 0 {
+			h.logger.Warn(
+				"ViteTemplate is not set, so no React preamble is injected; set vite.React for React apps, or vite.None to silence this warning",
+			)
+		}
 
 		if config.PublicFS == nil {
 			// We will peek into the "public" directory of the Vite app, and
@@ -84,19 +251,109 @@ func NewHandler(config Config) (*Handler, error) {
 			pub, err := fs.Sub(config.FS, "public")
 			if err == nil {
 				h.pub = pub
-				h.pubFS = http.FS(h.pub)
 				h.pubHandler = http.FileServerFS(h.pub)
 			}
 		} else {
 			h.pub = config.PublicFS
-			h.pubFS = http.FS(config.PublicFS)
 			h.pubHandler = http.FileServerFS(config.PublicFS)
 		}
+		if config.PublicPrefix != "" {
+			h.publicPrefix = normalizeBase(config.PublicPrefix)
+		} else if h.pub != nil {
+			// Public files are served first, so they shadow files of FS
+			// with the same path.
+			if conflicts, err := OverlayFS(h.pub, h.fs).Conflicts(); err == nil && len(conflicts) > 0 {
+				h.logger.Warn(
+					"Public files shadow files of FS",
+					"files", conflicts,
+				)
+			}
+		}
+		if h.pub != nil && config.PublicCacheTTL > 0 {
+			h.pubExists = newExistsCache(h.pub, config.PublicCacheTTL)
+		}
 	}
 
 	return h, nil
 }
 
+// ReloadManifest reads the Vite manifest from the file system again, e.g.
+// after a new version of the Vite app has been deployed under a running
+// server. If reading or parsing the manifest fails, the handler keeps using
+// the current manifest. It is a no-op in development mode and if the handler
+// was created with a pre-parsed manifest.
+func (h *Handler) ReloadManifest() error {
+	if h.parent != nil {
+		return h.parent.ReloadManifest()
+	}
+	if h.isDev || h.manifestPath == "" {
+		return nil
+	}
+	m, err := h.readManifest()
+	if err != nil {
+		return err
+	}
+	h.manifest.Store(m)
+	h.logger.Debug("Loaded manifest", "path", h.manifestPath, "chunks", len(*m))
+	h.events.manifestLoaded.publish(ManifestLoadedEvent{Path: h.manifestPath, Manifest: m})
+	return nil
+}
+
+// readManifest reads and parses the Vite manifest.
+func (h *Handler) readManifest() (*Manifest, error) {
+	return readManifestFile(h.fs, h.manifestPath)
+}
+
+// WatchManifest polls the Vite manifest for changes every interval and
+// reloads it when its content changes. It blocks until ctx is canceled, so
+// run it in a goroutine:
+//
+//	go h.WatchManifest(ctx, 5*time.Second)
+//
+// Errors while reloading are logged and the current manifest is kept. It
+// returns immediately in development mode and if the handler was created
+// with a pre-parsed manifest.
+func (h *Handler) WatchManifest(ctx context.Context, interval time.Duration) {
+	if h.parent != nil {
+		h.parent.WatchManifest(ctx, interval)
+		return
+	}
+	if h.isDev || h.manifestPath == "" {
+		return
+	}
+
+	last, _ := fs.ReadFile(h.fs, h.manifestPath)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data, err := fs.ReadFile(h.fs, h.manifestPath)
+		if err != nil {
+			h.logger.Warn("Unable to read manifest", "path", h.manifestPath, "error", err)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		m, err := ParseManifest(bytes.NewReader(data))
+		if err != nil {
+			// The manifest might be written right now; try again next time.
+			h.logger.Warn("Unable to parse manifest", "path", h.manifestPath, "error", err)
+			continue
+		}
+		h.manifest.Store(m)
+		last = data
+		h.logger.Info("Reloaded manifest", "path", h.manifestPath)
+		h.events.manifestLoaded.publish(ManifestLoadedEvent{Path: h.manifestPath, Manifest: m})
+	}
+}
+
 // SetDefaultMetadata sets the default metadata to use when rendering the
 // page. This metadata is used when the context does not have any metadata.
 func (h *Handler) SetDefaultMetadata(md *Metadata) {
@@ -119,7 +376,115 @@ func (h *Handler) RegisterTemplate(name, text string) {
 	if _, ok := h.templates[name]; ok {
 		panic(fmt.Sprintf("vite: template %q already registered", name))
 	}
-	h.templates[name] = template.Must(template.New(name).Parse(text))
+	h.templates[name] = template.Must(template.New(name).Funcs(h.templateFuncs()).Parse(text))
+}
+
+// templateFuncs returns the functions available in registered templates:
+//
+//   - viteAsset returns the URL of a static asset, see [Handler.AssetURL].
+//   - vitePrecache returns the precache entries as a JavaScript array, see
+//     [Handler.PrecacheEntries].
+//   - preloadImage returns a preload link for an image, see
+//     [Handler.PreloadImage].
+//   - urljoin joins a base URL and paths, see [url.JoinPath].
+//   - viteEnv writes data for the client helper, see [ClientEnvScript].
+//   - viteScriptAttrs returns the attributes of the module script of an
+//     entry point, see Config.ScriptAttributes.
+//   - viteMountID returns the id of the element an entry point mounts
+//     into, see [MountID].
+//
+// It also returns the functions added with [Handler.RegisterTemplateFuncs].
+func (h *Handler) templateFuncs() template.FuncMap {
+	if h.parent != nil {
+		return h.parent.templateFuncs()
+	}
+	funcs := template.FuncMap{
+		"viteAsset":    h.AssetURL,
+		"vitePrecache": h.precacheJS,
+		"preloadImage": h.PreloadImage,
+		"urljoin":      url.JoinPath,
+		"viteEnv":      ClientEnvScript,
+		"viteScriptAttrs": func(entry string) template.HTMLAttr {
+			return h.scriptAttrs[strings.TrimPrefix(entry, "/")].html()
+		},
+		"viteMountID": MountID,
+	}
+	for name, fn := range h.funcs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// RegisterTemplateFuncs adds functions to the templates of the handler,
+// including the fallback template, e.g. for i18n. Functions with the name
+// of an existing function replace it.
+//
+// Templates are parsed on registration, so call RegisterTemplateFuncs
+// before registering templates that use the functions. Templates
+// registered before get the functions as well, which allows to replace
+// a function in all of them. The functions apply to the host views and
+// groups of the handler as well, also if called on a view or group.
+func (h *Handler) RegisterTemplateFuncs(funcs template.FuncMap) {
+	if h.parent != nil {
+		h.parent.RegisterTemplateFuncs(funcs)
+		return
+	}
+	merged := make(template.FuncMap, len(h.funcs)+len(funcs))
+	for name, fn := range h.funcs {
+		merged[name] = fn
+	}
+	for name, fn := range funcs {
+		merged[name] = fn
+	}
+	h.funcs = merged
+
+	for _, tmpl := range h.templates {
+		tmpl.Funcs(funcs)
+	}
+	h.hosts.mu.RLock()
+	defer h.hosts.mu.RUnlock()
+	for _, v := range h.hosts.m {
+		for _, tmpl := range v.templates {
+			tmpl.Funcs(funcs)
+		}
+	}
+	h.groups.mu.Lock()
+	defer h.groups.mu.Unlock()
+	for _, g := range h.groups.m {
+		for _, tmpl := range g.templates {
+			tmpl.Funcs(funcs)
+		}
+	}
+}
+
+// AssetURL returns the URL of a static asset imported by the Vite app, e.g.
+// an image or a font. In production mode, it returns the URL of the
+// fingerprinted file from the manifest, e.g. "/assets/logo-Dk3p.png" for
+// "src/assets/logo.png" (see [Manifest.AssetURL]). In development mode, it
+// returns the URL of the source file on the Vite server.
+//
+// If the asset is not in the manifest, src is returned as an absolute path.
+func (h *Handler) AssetURL(src string) string {
+	if h.parent != nil {
+		return h.parent.AssetURL(src)
+	}
+	if h.isDev {
+		u, err := url.JoinPath(h.viteURL, src)
+		if err != nil {
+			return src
+		}
+		return u
+	}
+	if u, ok := h.manifestIndex().assetURL(src, h.base); ok {
+		return u
+	}
+	return h.base + strings.TrimPrefix(src, "/")
+}
+
+// hasTemplate returns true if a template with the given name is registered,
+// either with the handler or with the template engine.
+func (h *Handler) hasTemplate(name string) bool {
+	return h.templateRegistered(name) || h.engine != nil && h.engine.Lookup(name)
 }
 
 // HandlerFunc returns a http.HandlerFunc for h.
@@ -129,48 +494,168 @@ func (h *Handler) HandlerFunc() http.HandlerFunc {
 
 // ServeHTTP handles HTTP requests.
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Serve requests for groups and for hosts with a view of their own
+	// with the handler they belong to.
+	if h.parent != nil && h.group {
+		h.parent.serveGroup(w, r, h)
+		return
+	}
+	if h.parent != nil {
+		h.parent.serveHost(w, r, h)
+		return
+	}
+	if h.host == nil {
+		if v := h.hosts.lookup(r.Host); v != nil {
+			h.serveHost(w, r, v)
+			return
+		}
+	}
+
 	// Normalize the path, e.g. /..//articles/123/ -> /articles/123
 	path := path.Clean(r.URL.Path)
 
 	isIndexPath := path == "/" || path == "/index.html"
 
+	if h.vitals != nil && path == h.vitalsPath {
+		h.vitals.ServeHTTP(w, r)
+		return
+	}
+	if h.assets != nil && !h.isDev && path == AssetsManifestPath {
+		h.serveAssetsManifest(w, r)
+		return
+	}
+	if h.isDev && path == ClientPath {
+		h.serveClient(w, r)
+		return
+	}
+
+	// Make the path relative to the base, e.g. /app/about -> /about, and
+	// serve files with the relative path.
+	orig := r
+	if p, ok := stripBase(path, h.base); ok && p != path {
+		path = p
+		r = r.Clone(r.Context())
+		r.URL.Path = path
+		r.URL.RawPath = ""
+		isIndexPath = path == "/" || path == "/index.html"
+	}
+
 	// Check if the file exists in the public directory.
-	if h.isDev && h.pubFS != nil && h.pubHandler != nil && !isIndexPath {
-		if _, err := h.pubFS.Open(path); err == nil {
+	if h.isDev && h.pub != nil && h.pubHandler != nil && !isIndexPath {
+		if pubPath, ok := h.publicPath(path); ok && h.publicFileExists(pubPath) {
+			h.metrics.assetRequests.Add(1)
+			h.setCacheControl(w, path)
+			if pubPath != path {
+				r = r.Clone(r.Context())
+				r.URL.Path = pubPath
+				r.URL.RawPath = ""
+			}
 			h.pubHandler.ServeHTTP(w, r)
+			h.events.assetServed.publish(AssetServedEvent{Request: r, Path: path, Public: true})
 			return
 		}
 	}
 
+	if entry, ok := h.routes.lookup(r); ok && (isIndexPath || !fileExists(h.fs, path)) {
+		// The path is a route of the frontend, so we render the page with
+		// the entry point of the route, unless the request sets one.
+		if len(RenderOptionsFromContext(orig.Context()).entries()) == 0 {
+			orig = orig.WithContext(EntriesToContext(orig.Context(), entry))
+		}
+		h.renderPage(w, orig, path, nil)
+		return
+	}
+
 	if isIndexPath {
 		// We didn't find it in the file system, so we generate the HTML
 		// from the entry point with Go templating.
-		h.renderPage(w, r, path, nil)
+		h.renderPage(w, orig, path, nil)
 		return
 	}
 
-	if _, ok := h.templates[path]; ok {
+	if h.hasTemplate(path) {
 		// We found a template for the path, so we render the page using
 		// the template.
-		h.renderPage(w, r, path, nil)
+		h.renderPage(w, orig, path, nil)
 		return
 	}
 
 	// Check if the file exists in the file system.
-	if _, err := h.fsFS.Open(path); err != nil {
+	if !fileExists(h.fs, path) {
+		// Render the page with the template of a section of the path,
+		// e.g. "/admin" for "/admin/users", if there is one.
+		if h.hasSectionTemplate(path) {
+			h.renderPage(w, orig, path, nil)
+			return
+		}
 		// The file does not exist in the file system, so 404.
-		http.NotFound(w, r)
+		if h.missing.matches(path) {
+			h.missing.record(path)
+		}
+		h.serveNotFound(w, orig)
 		return
 	}
 
 	// Serve the file using the file server.
+	h.metrics.assetRequests.Add(1)
+	h.setCacheControl(w, path)
 	h.fsHandler.ServeHTTP(w, r)
+	h.events.assetServed.publish(AssetServedEvent{Request: r, Path: path})
+}
+
+// publicPath returns the path of a file in the public directory for the
+// given URL path, and false if the path is not under PublicPrefix.
+func (h *Handler) publicPath(path string) (string, bool) {
+	if h.publicPrefix == "" {
+		return path, true
+	}
+	rest, ok := strings.CutPrefix(path, h.publicPrefix)
+	if !ok || rest == "" {
+		return "", false
+	}
+	return "/" + rest, true
+}
+
+// fileExists returns true if the file at the URL path exists in fsys. It
+// uses fs.Stat, so that checking a large file, e.g. a wasm binary, does
+// not open it before the file server does.
+func fileExists(fsys fs.FS, path string) bool {
+	name := strings.TrimPrefix(path, "/")
+	if name == "" {
+		name = "."
+	}
+	_, err := fs.Stat(fsys, name)
+	return err == nil
+}
+
+// serveNotFound replies to the request with a 404 Not Found, using the
+// configured NotFoundHandler, if any.
+func (h *Handler) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	h.metrics.notFound.Add(1)
+	if h.notFound != nil {
+		h.notFound.ServeHTTP(w, r)
+		return
+	}
+	http.NotFound(w, r)
 }
 
-// pageData is passed to the template when rendering the page.
-type pageData struct {
+// serveError replies to the request with a 500 Internal Server Error, using
+// the configured ErrorHandler, if any.
+func (h *Handler) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	h.metrics.renderErrors.Add(1)
+	spanFromContext(r.Context()).RecordError(err)
+	if h.onError != nil {
+		h.onError(w, r, err)
+		return
+	}
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// PageData is passed to the template when rendering the page.
+type PageData struct {
 	IsDev               bool
 	ViteEntry           string
+	ViteEntries         []string
 	ViteURL             string
 	Metadata            template.HTML
 	PluginReactPreamble template.HTML
@@ -178,128 +663,325 @@ type pageData struct {
 	Modules             template.HTML
 	PreloadModules      template.HTML
 	Scripts             template.HTML
+	BodyModules         template.HTML
+	NoScript            template.HTML
+	SSR                 template.HTML
+	Mounts              []Mount
+	IsBot               bool
+	ViteUnreachable     bool
+	Budget              AssetBudget
+	Data                any
+
+	md *Metadata // the metadata rendered into Metadata, if any
 }
 
 // renderPage renders the page using the template.
 func (h *Handler) renderPage(w http.ResponseWriter, r *http.Request, path string, chunk *Chunk) {
-	page := pageData{
+	h.metrics.pagesRendered.Add(1)
+	start := time.Now()
+	defer h.metrics.renderLatency.observeSince(start)
+
+	page := PageData{
 		IsDev:     h.isDev,
 		ViteEntry: h.viteEntry,
 		ViteURL:   h.viteURL,
+		NoScript:  h.noScript,
+	}
+
+	ctx, span := h.startSpan(r.Context(), "vite.render_page", SpanAttribute{Key: "vite.path", Value: path})
+	defer span.End()
+	ctx = context.WithValue(ctx, spanKey, span)
+	r = r.WithContext(ctx)
+
+	opts := RenderOptionsFromContext(ctx)
+	page.Data = opts.Data
+
+	// Use the entry points of the request or the configuration, if any.
+	// The first one is the primary entry point.
+	entries := opts.entries()
+	if len(entries) == 0 {
+		entries = h.viteEntries
+	}
+	if len(entries) > 0 {
+		page.ViteEntry = entries[0]
+		page.ViteEntries = entries
+	}
+
+	// Adapt the page to the client hints, if configured.
+	var adapted *Adaptation
+	if h.adapt != nil {
+		w.Header().Set("Accept-CH", clientHintHeaders)
+		w.Header().Add("Vary", clientHintHeaders)
+		entry := page.ViteEntry
+		if entry == "" && !h.isDev {
+			entry, _ = h.manifestIndex().lookupEntryPoint("")
+		}
+		a := h.adapt(r, ParseClientHints(r), Adaptation{
+			Entry:   entry,
+			Preload: preloadOptionsFor(h.preload, h.preloadPolicies, entry),
+		})
+		page.ViteEntry = a.Entry
+		if len(page.ViteEntries) > 0 {
+			page.ViteEntries = append([]string{a.Entry}, page.ViteEntries[1:]...)
+		}
+		adapted = &a
 	}
+	page.Mounts = mounts(&page)
 
-	// Inject metadata in// Check if the specified Vite template requires a preamble and set the
-	// corresponding preamble string in the plugin configuration.
-	//
-	// If the Vite template value is less than 1, it is considered as an
-	// uninitialized state, and the default React preamble is applied.
-	// Otherwise, if the template requires a preamble, it uses the
-	// specific preamble for the given Vite template.to the page.
-	ctx := r.Context()
-	md := MetadataFromContext(ctx)
+	// Inject metadata into the page.
+	md := opts.Metadata
 	if md == nil {
-		md = h.defaultMetadata
+		md = h.getDefaultMetadata()
 	}
 	if md != nil {
 		page.Metadata = template.HTML(md.String())
+		page.md = md
+	}
+
+	// Reply with the data of the page to clients that ask for JSON, if
+	// configured.
+	if h.servePageJSON {
+		w.Header().Add("Vary", "Accept")
+		if acceptsJSON(r) {
+			h.servePageData(w, r, page, md)
+			return
+		}
+	}
+
+	// Connect early to the origins of scripts and stylesheets, if they
+	// differ from the origin of the page.
+	if !h.disableResourceHints {
+		if hints := resourceHints(r, h.assetOrigins()...); hints != "" {
+			page.Metadata = template.HTML(hints) + page.Metadata
+		}
 	}
 
 	// Inject scripts into the page.
-	scripts := ScriptsFromContext(ctx)
-	if scripts != "" {
-		page.Scripts = template.HTML(scripts)
+	if opts.Scripts != "" {
+		page.Scripts = template.HTML(opts.Scripts)
+	}
+
+	// Check whether the request comes from a crawler.
+	if h.isBot != nil {
+		w.Header().Add("Vary", "User-Agent")
+		page.IsBot = h.isBot(r)
+	}
+
+	// Render the page on the server, if configured. Streamed bodies take
+	// precedence.
+	if h.ssr != nil && h.bodyStream == nil && (!h.ssrForBotsOnly || page.IsBot) {
+		ssrCtx, ssrSpan := h.startSpan(ctx, "vite.ssr", SpanAttribute{Key: "vite.url", Value: r.URL.RequestURI()})
+		html, err := h.renderSSR(ssrCtx, w, r)
+		if err != nil {
+			ssrSpan.RecordError(err)
+		}
+		ssrSpan.End()
+		if err != nil {
+			h.logger.Warn(
+				"SSR failed",
+				"url", r.URL.RequestURI(),
+				"error", err,
+			)
+		} else {
+			page.SSR = template.HTML(html)
+		}
 	}
 
 	// Handle both development and production modes.
-	if h.isDev {
+	var version string
+	if h.isDev && h.devProbe != nil && !h.devProbe.isReachable(ctx) {
+		// Render the page without the Vite client and the entry points.
+		page.ViteUnreachable = true
+		version = "dev"
+	} else if h.isDev {
 		// Check if the specified Vite template requires a preamble and set the
-		// corresponding preamble string in the plugin configuration.
-		//
-		// If the Vite template value is less than 1, it is considered as an
-		// uninitialized state, and the default React preamble is applied.
-		// Otherwise, if the template requires a preamble, it uses the
-		// specific preamble for the given Vite template.
-		if h.viteTemplate < 1 {
-			page.PluginReactPreamble = template.HTML(React.Preamble(h.viteURL))
-		} else if h.viteTemplate.RequiresPreamble() {
+		// corresponding preamble string in the plugin configuration. Without
+		// a Vite template, no preamble is applied.
+		if h.viteTemplate.RequiresPreamble() {
 			page.PluginReactPreamble = template.HTML(h.viteTemplate.Preamble(h.viteURL))
 		}
-		// page.PluginReactPreamble = template.HTML(PluginReactPreamble(h.viteURL))
+		devStyleSheets(&page)
+		version = "dev"
 	} else {
-		if chunk == nil {
-			if page.ViteEntry == "" {
-				chunk = h.manifest.GetEntryPoint()
-			} else {
-				entries := h.manifest.GetEntryPoints()
-				for _, entry := range entries {
-					if page.ViteEntry == entry.Src {
-						chunk = entry
-						break
-					}
-				}
+		index := h.manifestIndex()
+		manifest := index.manifest
+		var keys []string
+		if chunk != nil {
+			keys = []string{chunk.Src}
+		} else {
+			entries := page.ViteEntries
+			if len(entries) == 0 {
+				entries = []string{page.ViteEntry}
 			}
-			if chunk == nil {
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			var err error
+			_, lookupSpan := h.startSpan(ctx, "vite.manifest_lookup", SpanAttribute{Key: "vite.entries", Value: strings.Join(entries, ",")})
+			keys, err = index.lookupEntryPoints(entries)
+			if err != nil {
+				lookupSpan.RecordError(err)
+			}
+			lookupSpan.End()
+			if err != nil {
+				h.logger.Warn("Unable to render page", "path", path, "error", err)
+				h.serveError(w, r, err)
 				return
 			}
+			chunk, _ = manifest.GetChunk(keys[0])
+			h.logger.Debug("Resolved entry points", "path", path, "entries", entries, "chunks", keys)
+		}
+		css, err := manifest.cssURLs(h.base, keys...)
+		if err != nil {
+			h.logger.Warn("Stylesheets of page are incomplete", "path", path, "error", err)
 		}
-		page.StyleSheets = template.HTML(h.manifest.GenerateCSS(chunk.Src))
-		page.Modules = template.HTML(h.manifest.GenerateModules(chunk.Src))
-		page.PreloadModules = template.HTML(h.manifest.GeneratePreloadModules(chunk.Src))
+		var modules strings.Builder
+		for _, key := range keys {
+			urls, err := manifest.moduleURLs(h.base, key)
+			if err != nil {
+				h.logger.Warn("Modules of page are incomplete", "path", path, "error", err)
+			}
+			modules.WriteString(moduleTags(urls, h.scriptAttrs[key]))
+		}
+		page.StyleSheets = template.HTML(cssTags(css))
+		page.Modules = template.HTML(modules.String())
+		preload := preloadOptionsFor(h.preload, h.preloadPolicies, keys[0])
+		if adapted != nil {
+			preload = adapted.Preload
+		}
+		page.PreloadModules = template.HTML(manifest.generatePreloadModules(h.base, preload, keys...))
+		page.Budget = h.assetBudget(index, keys)
+		version = chunk.File
 	}
 
-	var tmplName string
-	if path == "/" {
-		tmplName = "index.html"
-	} else {
-		tmplName = path
-	}
-
-	// Find the template by name.
-	tmpl, ok := h.templates[tmplName]
-
-	// Catch common variations. If a template isn't found by the exact name,
-	// check for variations like: "page", "page.html", or "/page.html", to match
-	// how users might have registered the template.
-	if !ok {
-		variations := []string{
-			strings.TrimPrefix(tmplName, "/"),
-			strings.TrimPrefix(tmplName, "/") + ".html",
-			strings.TrimSuffix(strings.TrimPrefix(tmplName, "/"), ".html"),
-			tmplName + ".html",
-		}
-		for _, variant := range variations {
-			if t, found := h.templates[variant]; found {
-				tmpl = t
-				ok = true
-				break
-			}
+	// Move the module scripts to the end of the body, if configured.
+	if h.modulesAtBodyEnd && !page.ViteUnreachable {
+		if h.isDev {
+			page.BodyModules = devModules(&page, h.scriptAttrs)
+		} else {
+			page.BodyModules, page.Modules = page.Modules, ""
 		}
 	}
 
+	// Inject the web vitals script into the page, if configured.
+	if h.vitals != nil {
+		page.Scripts += VitalsScript(h.vitalsPath, version, opts.Nonce)
+	}
+
+	// Load the client helper in development mode.
+	if h.isDev && !page.ViteUnreachable {
+		page.Scripts += clientScript(opts.Nonce)
+	}
+
+	tmplName := fallbackTemplateName
+	if chain := h.templateChain(path); len(chain) > 0 {
+		tmplName = chain[0]
+	}
+	span.SetAttributes(
+		SpanAttribute{Key: "vite.entry", Value: page.ViteEntry},
+		SpanAttribute{Key: "vite.template", Value: tmplName},
+	)
+	h.writePage(w, r, page, h.lookupTemplate(path))
+	h.events.render.publish(RenderEvent{
+		Request:  r,
+		Path:     path,
+		Template: tmplName,
+		Entry:    page.ViteEntry,
+		Duration: time.Since(start),
+	})
+}
+
+// executeFunc executes a template with the page data.
+type executeFunc func(w io.Writer, page PageData) error
+
+// lookupTemplate finds the template of the page at path, following the
+// template chain, see Config.TemplateChain.
+func (h *Handler) lookupTemplate(path string) executeFunc {
+	if name, pos, ok := h.findChainTemplate(path); ok {
+		h.metrics.recordTemplateHit(pos == 0 && name == h.templateChain(path)[0])
+		return h.executeTemplate(name)
+	}
+
 	// Handle case when requested template is not found:
 	// 1. If multiple templates exist, log a warning with the requested and available templates.
 	// 2. Fall back to a default template.
-	if !ok {
-		if len(h.templates) > 1 {
-			keys := make([]string, 0, len(h.templates))
-			for k := range h.templates {
-				keys = append(keys, k)
-			}
-			slog.Warn(
-				"Template not found",
-				"requestedTemplate", tmplName,
-				"availableTemplates", strings.Join(keys, ", "),
-			)
+	if names := h.templateNames(); len(names) > 1 || h.engine != nil {
+		h.logger.Warn(
+			"Template not found",
+			"requestedTemplate", strings.Join(h.templateChain(path), ", "),
+			"availableTemplates", strings.Join(names, ", "),
+		)
+	}
+	h.metrics.templateMisses.Add(1)
+	if execute, ok := h.staticIndex(); ok {
+		return execute
+	}
+	tmpl, _ := h.findTemplate(fallbackTemplateName)
+	return func(w io.Writer, page PageData) error {
+		return tmpl.Execute(w, page)
+	}
+}
+
+// bodyStreamMarker marks the position of the streamed body in the rendered
+// template. It is passed to the template in the SSR slot.
+const bodyStreamMarker = "<!--vite:body-stream-->"
+
+// writePage executes the template and writes the page to w. If a body
+// stream func is configured, the page is split at the SSR slot: everything
+// before it is written and flushed immediately, then the body is streamed,
+// and finally the rest of the page is written.
+func (h *Handler) writePage(w http.ResponseWriter, r *http.Request, page PageData, execute executeFunc) {
+	if h.bodyStream == nil && h.validateHTML {
+		var buf bytes.Buffer
+		if err := execute(&buf, page); err != nil {
+			h.serveError(w, r, fmt.Errorf("vite: execute template: %w", err))
+			return
 		}
-		tmpl = h.templates[fallbackTemplateName]
+		h.checkPage(r, buf.Bytes())
+		_, _ = w.Write(buf.Bytes())
+		return
+	}
+	if h.bodyStream == nil {
+		if err := execute(w, page); err != nil {
+			h.serveError(w, r, fmt.Errorf("vite: execute template: %w", err))
+		}
+		return
+	}
+
+	page.SSR = bodyStreamMarker
+	var buf bytes.Buffer
+	if err := execute(&buf, page); err != nil {
+		h.serveError(w, r, fmt.Errorf("vite: execute template: %w", err))
+		return
+	}
+	if h.validateHTML {
+		h.checkPage(r, buf.Bytes())
+	}
+	head, tail, found := bytes.Cut(buf.Bytes(), []byte(bodyStreamMarker))
+	if !found {
+		h.logger.Warn(
+			"Template has no SSR slot to stream the body into",
+			"url", r.URL.RequestURI(),
+		)
+		_, _ = w.Write(head)
+		return
 	}
 
-	// Execute the template.
-	if err := tmpl.Execute(w, page); err != nil {
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if _, err := w.Write(head); err != nil {
 		return
 	}
+	_ = http.NewResponseController(w).Flush()
+
+	if err := h.bodyStream(w, r); err != nil {
+		// The status code has already been sent, so all we can do is log.
+		h.logger.Warn(
+			"Streaming body failed",
+			"url", r.URL.RequestURI(),
+			"error", err,
+		)
+	}
+
+	_, _ = w.Write(tail)
 }
 
 const fallbackTemplateName = "fallback.html"
@@ -312,13 +994,21 @@ var (
 	{{- if .Metadata }}
 		{{ .Metadata }}
 	{{- end }}
-	{{- if .IsDev }}
+	{{- if .ViteUnreachable }}
+	{{- else if .IsDev }}
 		{{ .PluginReactPreamble }}
+		{{- if .StyleSheets }}
+		{{ .StyleSheets }}
+		{{- end }}
+		{{- if not .BodyModules }}
 		<script type="module" src="{{ .ViteURL }}/@vite/client"></script>
-		{{- if ne .ViteEntry "" }}
-			<script type="module" src="{{ .ViteURL }}/{{ .ViteEntry }}"></script>
-		{{- else }}
-			<script type="module" src="{{ .ViteURL }}/src/main.tsx"></script>
+		{{- if .ViteEntries }}
+			{{- range .ViteEntries }}
+			<script type="module" src="{{ $.ViteURL }}/{{ . }}"{{ viteScriptAttrs . }}></script>
+			{{- end }}
+		{{- else if ne .ViteEntry "" }}
+			<script type="module" src="{{ .ViteURL }}/{{ .ViteEntry }}"{{ viteScriptAttrs .ViteEntry }}></script>
+		{{- end }}
 		{{- end }}
 	{{- else }}
 		{{- if .StyleSheets }}
@@ -336,7 +1026,19 @@ var (
 	{{- end }}
  </head>
   <body class="min-h-screen antialiased">
-    <div id="root"></div>
+	{{- if .ViteUnreachable }}
+    <div role="alert" style="padding:0.5rem 1rem;background:#fef3c7;color:#78350f;font:14px/1.5 system-ui,sans-serif">The Vite dev server at {{ .ViteURL }} is not reachable. Start it with <code>npm run dev</code> and reload the page.</div>
+	{{- end }}
+    <div id="root">{{ .SSR }}</div>
+	{{- range $i, $mount := .Mounts }}{{ if $i }}
+    <div id="{{ $mount.ID }}"></div>
+	{{- end }}{{ end }}
+	{{- if .NoScript }}
+    <noscript>{{ .NoScript }}</noscript>
+	{{- end }}
+	{{- if .BodyModules }}
+    {{ .BodyModules }}
+	{{- end }}
   </body>
 </html>
 `
@@ -0,0 +1,2255 @@
+package vite_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/olivere/vite"
+)
+
+func TestHandlerRendersSSRIntoRoot(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS: getTestFS(),
+		SSR: vite.SSRRendererFunc(func(ctx context.Context, url string) (string, error) {
+			return "<p>rendered " + url + "</p>", nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?tab=1", nil))
+
+	if want := `<div id="root"><p>rendered /?tab=1</p></div>`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+}
+
+func TestHandlerFallsBackToCSRWhenSSRFails(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS: getTestFS(),
+		SSR: vite.SSRRendererFunc(func(ctx context.Context, url string) (string, error) {
+			return "", errors.New("node is gone")
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if want := `<div id="root"></div>`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+}
+
+type testEngine map[string]string
+
+func (e testEngine) Lookup(name string) bool {
+	_, ok := e[name]
+	return ok
+}
+
+func (e testEngine) Execute(w io.Writer, name string, data vite.PageData) error {
+	_, err := fmt.Fprintf(w, e[name], data.ViteURL)
+	return err
+}
+
+func TestHandlerUsesTemplateEngine(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:      getTestFS(),
+		IsDev:   true,
+		ViteURL: "http://localhost:5173",
+		TemplateEngine: testEngine{
+			"/about": "about page with vite at %s",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if want := "about page with vite at http://localhost:5173"; rec.Body.String() != want {
+		t.Fatalf("expected %q, got %q", want, rec.Body.String())
+	}
+
+	// Pages not known to the engine use the fallback template.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `<div id="root"></div>`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+}
+
+func TestHandlerStreamsBodyIntoRoot(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS: getTestFS(),
+		BodyStreamFunc: func(w http.ResponseWriter, r *http.Request) error {
+			for i := range 3 {
+				fmt.Fprintf(w, "<p>chunk %d</p>", i)
+				http.NewResponseController(w).Flush()
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := rec.Body.String()
+	if want := `<div id="root"><p>chunk 0</p><p>chunk 1</p><p>chunk 2</p></div>`; !strings.Contains(body, want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, body)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(body), "</html>") {
+		t.Fatalf("expected body to end with closing markup, got:\n%s", body)
+	}
+}
+
+func TestHandlerReloadManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{
+			Data: []byte(`{"src/main.tsx":{"file":"assets/main-v1.js","src":"src/main.tsx","isEntry":true}}`),
+		},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Body.String()
+	}
+	if want := `src="/assets/main-v1.js"`; !strings.Contains(render(), want) {
+		t.Fatalf("expected body to contain %s", want)
+	}
+
+	fsys[".vite/manifest.json"] = &fstest.MapFile{
+		Data: []byte(`{"src/main.tsx":{"file":"assets/main-v2.js","src":"src/main.tsx","isEntry":true}}`),
+	}
+	if err := h.ReloadManifest(); err != nil {
+		t.Fatal(err)
+	}
+	if want := `src="/assets/main-v2.js"`; !strings.Contains(render(), want) {
+		t.Fatalf("expected body to contain %s", want)
+	}
+
+	// A broken manifest keeps the current one.
+	fsys[".vite/manifest.json"] = &fstest.MapFile{Data: []byte(`{`)}
+	if err := h.ReloadManifest(); err == nil {
+		t.Fatal("expected an error for a broken manifest")
+	}
+	if want := `src="/assets/main-v2.js"`; !strings.Contains(render(), want) {
+		t.Fatalf("expected body to contain %s", want)
+	}
+}
+
+func TestHandlerViteAssetTemplateFunc(t *testing.T) {
+	const tmpl = `<img src="{{ viteAsset "src/assets/logo.png" }}">`
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{
+			Data: []byte(`{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true},
+  "src/assets/logo.png": {"file": "assets/logo-Dk3p.png", "src": "src/assets/logo.png"}
+}`),
+		},
+	}
+
+	for _, tt := range []struct {
+		isDev bool
+		want  string
+	}{
+		{false, `<img src="/assets/logo-Dk3p.png">`},
+		{true, `<img src="http://localhost:5173/src/assets/logo.png">`},
+	} {
+		h, err := vite.NewHandler(vite.Config{FS: fsys, IsDev: tt.isDev})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.RegisterTemplate("/logo", tmpl)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logo", nil))
+		if have := rec.Body.String(); tt.want != have {
+			t.Errorf("isDev=%v: expected %q, got %q", tt.isDev, tt.want, have)
+		}
+	}
+}
+
+func TestHandlerPreloadImageTemplateFunc(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{
+			Data: []byte(`{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true},
+  "src/assets/hero.png": {"file": "assets/hero-Dk3p.png", "src": "src/assets/hero.png"},
+  "src/assets/hero.png?w=800": {"file": "assets/hero-800-Ab12.png", "src": "src/assets/hero.png?w=800"},
+  "src/assets/hero.png?w=400": {"file": "assets/hero-400-Cd34.png", "src": "src/assets/hero.png?w=400"}
+}`),
+		},
+	}
+
+	for _, tt := range []struct {
+		tmpl string
+		want string
+	}{
+		{
+			`{{ preloadImage "src/assets/hero.png" }}`,
+			`<link rel="preload" as="image" href="/assets/hero-Dk3p.png" imagesrcset="/assets/hero-400-Cd34.png 400w, /assets/hero-800-Ab12.png 800w" imagesizes="100vw" fetchpriority="high">`,
+		},
+		{
+			`{{ preloadImage "src/assets/hero.png" "(max-width: 600px) 100vw, 50vw" }}`,
+			`<link rel="preload" as="image" href="/assets/hero-Dk3p.png" imagesrcset="/assets/hero-400-Cd34.png 400w, /assets/hero-800-Ab12.png 800w" imagesizes="(max-width: 600px) 100vw, 50vw" fetchpriority="high">`,
+		},
+		{
+			`{{ preloadImage "src/assets/unknown.png" }}`,
+			`<link rel="preload" as="image" href="/src/assets/unknown.png" fetchpriority="high">`,
+		},
+	} {
+		h, err := vite.NewHandler(vite.Config{FS: fsys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.RegisterTemplate("/hero", tt.tmpl)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hero", nil))
+		if have := rec.Body.String(); tt.want != have {
+			t.Errorf("%s: expected %q, got %q", tt.tmpl, tt.want, have)
+		}
+	}
+}
+
+func TestHandlerRespectsClientHints(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:                 getTestFS(),
+		ViteEntry:          "views/foo.js",
+		RespectClientHints: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const preload = `<link rel="modulepreload" href="/assets/shared-B7PI925R.js">`
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), preload) {
+		t.Fatalf("expected body to contain %s", preload)
+	}
+	if want, have := "Save-Data, Downlink, ECT", rec.Header().Get("Accept-CH"); want != have {
+		t.Fatalf("expected Accept-CH %q, got %q", want, have)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Save-Data", "on")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "modulepreload") {
+		t.Fatalf("expected no modulepreload tags for Save-Data clients, got:\n%s", rec.Body.String())
+	}
+	if want := `<script type="module" src="/assets/foo-BRBmoGS9.js"></script>`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s", want)
+	}
+}
+
+func TestHandlerRendersSSRForBotsOnly(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS: getTestFS(),
+		SSR: vite.SSRRendererFunc(func(ctx context.Context, url string) (string, error) {
+			return "<p>prerendered</p>", nil
+		}),
+		BotDetector:    vite.NewBotDetector(),
+		SSRForBotsOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", `<div id="root"><p>prerendered</p></div>`},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15", `<div id="root"></div>`},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", tt.userAgent)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: expected body to contain %s, got:\n%s", tt.userAgent, tt.want, rec.Body.String())
+		}
+	}
+}
+
+func TestNewHandlerWithOptions(t *testing.T) {
+	h, err := vite.NewHandlerWithOptions(getTestFS(),
+		vite.WithDev(true),
+		vite.WithViteURL("http://localhost:3000"),
+		vite.WithEntry("src/main.ts"),
+		vite.WithScaffolding(vite.Vue),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `<script type="module" src="http://localhost:3000/src/main.ts"></script>`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	if _, err := vite.NewHandlerWithOptions(nil); err == nil {
+		t.Fatal("expected an error for a nil file system")
+	}
+}
+
+func TestWebVitals(t *testing.T) {
+	var recorded []vite.WebVital
+	h, err := vite.NewHandler(vite.Config{
+		FS:      getTestFS(),
+		IsDev:   true,
+		ViteURL: "http://localhost:5173",
+		VitalsRecorder: vite.VitalsRecorderFunc(func(r *http.Request, v vite.WebVital) {
+			recorded = append(recorded, v)
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(vite.NonceToContext(req.Context(), "abc123"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if want := `<script nonce="abc123" data-url="/__vitals" data-version="dev">`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	body := `[{"name":"LCP","value":1234.5,"id":"1","page":"/","version":"dev"},{"name":"CLS","value":0.01,"id":"1","page":"/","version":"dev"}]`
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/__vitals", strings.NewReader(body)))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, rec.Code)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("expected 2 vitals, got %d", len(recorded))
+	}
+	if want, have := (vite.WebVital{Name: "LCP", Value: 1234.5, ID: "1", Page: "/", Version: "dev"}), recorded[0]; want != have {
+		t.Fatalf("expected %+v, got %+v", want, have)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/__vitals", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
+
+func TestMissingAssets(t *testing.T) {
+	var calls []int
+	h, err := vite.NewHandler(vite.Config{
+		FS:                     getTestFS(),
+		IsDev:                  false,
+		MissingAssetsThreshold: 2,
+		OnMissingAssets: func(path string, count int) {
+			calls = append(calls, count)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/assets/a.js", "/favicon.png", "/assets/b.js", "/assets/c.js"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected status %d, got %d", path, http.StatusNotFound, rec.Code)
+		}
+	}
+
+	if want, have := uint64(3), h.MissingAssets(); want != have {
+		t.Fatalf("expected %d missing assets, got %d", want, have)
+	}
+	if len(calls) != 1 || calls[0] != 2 {
+		t.Fatalf("expected one call with count 2, got %v", calls)
+	}
+}
+
+func TestDevServerCheck(t *testing.T) {
+	devServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/@vite/client" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "// vite client")
+	}))
+	defer devServer.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	t.Run("candidate", func(t *testing.T) {
+		h, err := newDevServerCheckHandler(downURL, []string{devServer.URL}, vite.DevServerCheckRequire)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if want := devServer.URL + "/@vite/client"; !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+		}
+	})
+
+	t.Run("require", func(t *testing.T) {
+		if _, err := newDevServerCheckHandler(downURL, nil, vite.DevServerCheckRequire); err == nil {
+			t.Fatal("expected an error if the dev server is not reachable")
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		h, err := newDevServerCheckHandler(downURL, nil, vite.DevServerCheckFallback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if strings.Contains(rec.Body.String(), "@vite/client") {
+			t.Fatalf("expected a page in production mode, got:\n%s", rec.Body.String())
+		}
+	})
+}
+
+func newDevServerCheckHandler(viteURL string, candidates []string, check vite.DevServerCheck) (*vite.Handler, error) {
+	return vite.NewHandler(vite.Config{
+		FS:                  fstest.MapFS{},
+		IsDev:               true,
+		ViteURL:             viteURL,
+		DevServerCheck:      check,
+		DevServerCandidates: candidates,
+		FallbackFS:          getTestFS(),
+	})
+}
+
+func TestPrecacheHandler(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:    getTestFS(),
+		IsDev: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/sw-register", `<script>const precache = {{ vitePrecache "/" }};</script>`)
+
+	rec := httptest.NewRecorder()
+	h.PrecacheHandler("/").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/precache.json", nil))
+	if want, have := "application/json", rec.Header().Get("Content-Type"); want != have {
+		t.Fatalf("expected Content-Type %q, got %q", want, have)
+	}
+	var entries []vite.PrecacheEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("expected precache entries")
+	}
+
+	// Clients revalidate the entries with the ETag.
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	req := httptest.NewRequest(http.MethodGet, "/precache.json", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.PrecacheHandler("/").ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sw-register", nil))
+	if want := `const precache = [{"url":"` + entries[0].URL + `"`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+}
+
+func TestHandlerProbeDevServer(t *testing.T) {
+	devServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/@vite/client" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "// vite client")
+	}))
+	defer devServer.Close()
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	const banner = `role="alert"`
+	tests := []struct {
+		name      string
+		viteURL   string
+		reachable bool
+	}{
+		{"Reachable", devServer.URL, true},
+		{"Unreachable", downURL, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := vite.NewHandler(vite.Config{
+				FS:             fstest.MapFS{},
+				IsDev:          true,
+				ViteURL:        tt.viteURL,
+				ProbeDevServer: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+			}
+			body := rec.Body.String()
+			if have := strings.Contains(body, "/@vite/client"); tt.reachable != have {
+				t.Errorf("expected Vite client %v, got:\n%s", tt.reachable, body)
+			}
+			if have := strings.Contains(body, vite.ClientPath); tt.reachable != have {
+				t.Errorf("expected client helper %v, got:\n%s", tt.reachable, body)
+			}
+			if have := strings.Contains(body, banner); tt.reachable == have {
+				t.Errorf("expected banner %v, got:\n%s", !tt.reachable, body)
+			}
+		})
+	}
+}
+
+func TestWaitForViteServer(t *testing.T) {
+	var ready atomic.Bool
+	devServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() || r.URL.Path != "/@vite/client" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "// vite client")
+	}))
+	defer devServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := vite.WaitForViteServer(ctx, devServer.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	time.AfterFunc(300*time.Millisecond, func() { ready.Store(true) })
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := vite.WaitForViteServer(ctx, devServer.URL); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandlerGroup(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:    getTestFS(),
+		IsDev: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetDefaultMetadata(&vite.Metadata{Title: "App"})
+
+	docs := h.Group("docs")
+	if docs != h.Group("docs") {
+		t.Fatal("expected the same group for the same name")
+	}
+	docs.SetDefaultMetadata(&vite.Metadata{Title: "Docs"})
+	docs.RegisterTemplate("/guide", `<html><head>{{ .Metadata }}</head><body>Guide{{ .Modules }}</body></html>`)
+
+	tests := []struct {
+		handler *vite.Handler
+		path    string
+		status  int
+		want    string
+	}{
+		{h, "/", http.StatusOK, "<title>App</title>"},
+		{h, "/guide", http.StatusNotFound, ""},
+		{docs, "/", http.StatusOK, "<title>Docs</title>"},
+		{docs, "/guide", http.StatusOK, "Guide"},
+		{h.Group("blog"), "/", http.StatusOK, "<title>App</title>"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Fatalf("%s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Fatalf("%s: expected body to contain %s, got:\n%s", tt.path, tt.want, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerGroupUsesLaterChangesOfHandler(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:    getTestFS(),
+		IsDev: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplateFuncs(template.FuncMap{"section": func() string { return "none" }})
+	docs := h.Group("docs")
+	if docs.Group("blog") != h.Group("blog") {
+		t.Fatal("expected a group of a group to be a group of the handler")
+	}
+	docs.RegisterTemplate("/guide", `<html><head>{{ .Metadata }}</head><body>{{ section }}{{ .Modules }}</body></html>`)
+
+	// Functions and default metadata set on the handler after creating the
+	// group apply to the group.
+	h.RegisterTemplateFuncs(template.FuncMap{"section": func() string { return "Docs section" }})
+	h.SetDefaultMetadata(&vite.Metadata{Title: "App"})
+
+	rec := httptest.NewRecorder()
+	docs.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/guide", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	for _, want := range []string{"Docs section", "<title>App</title>"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerBase(t *testing.T) {
+	t.Run("production", func(t *testing.T) {
+		fsys := getTestFS().(fstest.MapFS)
+		fsys["assets/logo.svg"] = &fstest.MapFile{Data: []byte("<svg/>")}
+		h, err := vite.NewHandler(vite.Config{
+			FS:    fsys,
+			IsDev: false,
+			Base:  "/app/",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app/", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+		if want := `<script type="module" src="/app/assets/`; !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app/assets/logo.svg", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("development", func(t *testing.T) {
+		h, err := vite.NewHandler(vite.Config{
+			FS:       fstest.MapFS{},
+			PublicFS: fstest.MapFS{"favicon.svg": &fstest.MapFile{Data: []byte("<svg/>")}},
+			IsDev:    true,
+			ViteURL:  "http://localhost:5173",
+			Base:     "/app/",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app/", nil))
+		if want := `<script type="module" src="http://localhost:5173/app/@vite/client"></script>`; !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+		}
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app/favicon.svg", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	})
+}
+
+func TestHandlerPublicPrefix(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS: fstest.MapFS{},
+		PublicFS: fstest.MapFS{
+			"about":       &fstest.MapFile{Data: []byte("public about")},
+			"favicon.svg": &fstest.MapFile{Data: []byte("<svg/>")},
+		},
+		PublicPrefix: "/public",
+		IsDev:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/about", `<p>About</p>`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public/favicon.svg", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "<svg/>" {
+		t.Fatalf("expected the public file, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// Routes of the app are not shadowed by public files.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if want := "<p>About</p>"; rec.Body.String() != want {
+		t.Fatalf("expected %q, got %q", want, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/favicon.svg", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestHandlerPublicCacheTTL(t *testing.T) {
+	pub := fstest.MapFS{}
+	h, err := vite.NewHandler(vite.Config{
+		FS:             fstest.MapFS{},
+		PublicFS:       pub,
+		PublicCacheTTL: time.Hour,
+		IsDev:          true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	// The file does not show up before the TTL expires.
+	pub["robots.txt"] = &fstest.MapFile{Data: []byte("User-agent: *")}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d from the cache, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	// It does after the cache was invalidated, e.g. by a file watcher.
+	h.InvalidatePublicCache("robots.txt")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d after invalidating the cache, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestHandlerForHost(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:    getTestFS(),
+		IsDev: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetDefaultMetadata(&vite.Metadata{Title: "SaaS"})
+	h.RegisterTemplate("/pricing", `<p>Pricing</p>`)
+
+	acme := h.ForHost("ACME.example.com:8080")
+	if acme != h.ForHost("acme.example.com") {
+		t.Fatal("expected the same view for the same host")
+	}
+	acme.SetDefaultMetadata(&vite.Metadata{Title: "Acme"})
+	acme.RegisterTemplate("/pricing", `<p>Acme Pricing</p>`)
+
+	beta := h.ForHost("beta.example.com")
+	beta.RegisterTemplate("/about", `<p>About Beta</p>`)
+
+	tests := []struct {
+		host   string
+		path   string
+		status int
+		want   string
+	}{
+		{"example.com", "/", http.StatusOK, "<title>SaaS</title>"},
+		{"example.com", "/pricing", http.StatusOK, "<p>Pricing</p>"},
+		{"example.com", "/about", http.StatusNotFound, ""},
+		{"acme.example.com", "/", http.StatusOK, "<title>Acme</title>"},
+		{"acme.example.com:443", "/pricing", http.StatusOK, "<p>Acme Pricing</p>"},
+		{"beta.example.com", "/", http.StatusOK, "<title>SaaS</title>"},
+		{"beta.example.com", "/pricing", http.StatusOK, "<p>Pricing</p>"},
+		{"beta.example.com", "/about", http.StatusOK, "<p>About Beta</p>"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Fatalf("%s%s: expected status %d, got %d", tt.host, tt.path, tt.status, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Fatalf("%s%s: expected body to contain %s, got:\n%s", tt.host, tt.path, tt.want, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerForHostUsesLaterChangesOfHandler(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{FS: getTestFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := h.ForHost("acme.example.com")
+
+	// Changes of the handler after ForHost apply to the view.
+	h.SetDefaultMetadata(&vite.Metadata{Title: "SaaS"})
+	h.RegisterTemplate("/pricing", `<p>Pricing</p>`)
+	h.RegisterTemplateFuncs(template.FuncMap{"tenant": func() string { return "Tenant" }})
+	acme.RegisterTemplate("/about", `<p>{{ tenant }}</p>`)
+	h.RegisterTemplateFuncs(template.FuncMap{"tenant": func() string { return "Acme" }})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "<title>SaaS</title>"},
+		{"/pricing", "<p>Pricing</p>"},
+		{"/about", "<p>Acme</p>"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = "acme.example.com"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", tt.path, http.StatusOK, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: expected body to contain %s, got:\n%s", tt.path, tt.want, rec.Body.String())
+		}
+	}
+
+	// Serving with the view directly uses its overrides as well.
+	rec := httptest.NewRecorder()
+	acme.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if want := "<p>Acme</p>"; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+	if want, have := h.AssetURL("src/main.tsx"), acme.AssetURL("src/main.tsx"); want != have {
+		t.Errorf("expected asset URL %q of the view, got %q", want, have)
+	}
+}
+
+func TestHandlerMultipleEntries(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{
+			Data: []byte(`{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true, "imports": ["_shared.js"], "css": ["assets/main.css"]},
+  "src/analytics.ts": {"file": "assets/analytics.js", "src": "src/analytics.ts", "isEntry": true, "imports": ["_shared.js"]},
+  "_shared.js": {"file": "assets/shared.js", "css": ["assets/shared.css"]}
+}`),
+		},
+	}
+
+	h, err := vite.NewHandler(vite.Config{
+		FS:          fsys,
+		ViteEntries: []string{"src/analytics.ts", "src/main.tsx"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, tag := range []string{
+		`<script type="module" src="/assets/analytics.js"></script>`,
+		`<script type="module" src="/assets/main.js"></script>`,
+		`<link rel="stylesheet" href="/assets/main.css">`,
+		`<link rel="stylesheet" href="/assets/shared.css">`,
+		`<link rel="modulepreload" href="/assets/shared.js">`,
+	} {
+		if n := strings.Count(body, tag); n != 1 {
+			t.Errorf("expected body to contain %s once, got %d times:\n%s", tag, n, body)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(vite.EntriesToContext(req.Context(), "src/main.tsx"))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "analytics.js") {
+		t.Errorf("expected the entries of the context to override the config, got:\n%s", rec.Body.String())
+	}
+
+	ctx := vite.EntriesToContext(context.Background(), "src/analytics.ts", "src/main.tsx")
+	ctx = vite.EntryToContext(ctx, "src/analytics.ts")
+	if want, have := "src/analytics.ts", vite.EntryFromContext(ctx); want != have {
+		t.Errorf("expected entry %q, got %q", want, have)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if body := rec.Body.String(); !strings.Contains(body, "analytics.js") || strings.Contains(body, "main.js") {
+		t.Errorf("expected the entry of the context to override the config, got:\n%s", body)
+	}
+}
+
+func TestHandlerMountIDs(t *testing.T) {
+	for entry, want := range map[string]string{
+		"src/main.tsx":              "vite-src-main",
+		"/src/widgets/cart.tsx":     "vite-src-widgets-cart",
+		"src/islands/Cart Item.vue": "vite-src-islands-Cart-Item",
+		"main":                      "vite-main",
+	} {
+		if have := vite.MountID(entry); want != have {
+			t.Errorf("MountID(%q): expected %q, got %q", entry, want, have)
+		}
+	}
+
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{
+			Data: []byte(`{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true},
+  "src/widgets/cart.tsx": {"file": "assets/cart.js", "src": "src/widgets/cart.tsx", "isEntry": true},
+  "src/styles.css": {"file": "assets/styles.css", "src": "src/styles.css", "isEntry": true}
+}`),
+		},
+	}
+	config := vite.Config{
+		FS:            fsys,
+		ViteEntries:   []string{"src/main.tsx", "src/widgets/cart.tsx", "src/styles.css"},
+		ServePageData: true,
+	}
+	h, err := vite.NewHandler(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if want := `<div id="root"></div>
+    <div id="vite-src-widgets-cart"></div>`; !strings.Contains(body, want) {
+		t.Errorf("expected body to contain %s, got:\n%s", want, body)
+	}
+	if strings.Contains(body, "vite-src-styles") {
+		t.Errorf("expected no mount point for the stylesheet entry, got:\n%s", body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var data vite.PageDataJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	want := []vite.Mount{
+		{Entry: "src/main.tsx", ID: "vite-src-main"},
+		{Entry: "src/widgets/cart.tsx", ID: "vite-src-widgets-cart"},
+	}
+	if !reflect.DeepEqual(want, data.Mounts) {
+		t.Errorf("expected mounts %v, got %v", want, data.Mounts)
+	}
+
+	h.RegisterTemplate("index.html", `{{ range .Mounts }}<div id="{{ .ID }}"></div>{{ end }}<div id="{{ viteMountID "src/widgets/cart.tsx" }}"></div>`)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want, have := `<div id="vite-src-main"></div><div id="vite-src-widgets-cart"></div><div id="vite-src-widgets-cart"></div>`, rec.Body.String(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+
+	fragment, err := vite.HTMLFragments(config, "src/widgets/cart.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want[1:], fragment.Mounts) {
+		t.Errorf("expected fragment mounts %v, got %v", want[1:], fragment.Mounts)
+	}
+}
+
+func TestHandlerServePageData(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{FS: getTestFS(), ServePageData: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := vite.RenderOptionsToContext(context.Background(), vite.RenderOptions{
+		Metadata: &vite.Metadata{Title: "Profile", Description: "Your profile"},
+		Entry:    "views/foo.js",
+		Data:     map[string]string{"name": "Oliver"},
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if want, have := "application/json", rec.Header().Get("Content-Type"); want != have {
+		t.Fatalf("expected Content-Type %q, got %q", want, have)
+	}
+	var page vite.PageDataJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Title != "Profile" || page.Description != "Your profile" || !strings.Contains(page.Head, "<title>Profile</title>") {
+		t.Errorf("expected the metadata of the page, got %+v", page)
+	}
+	if want := []string{"views/foo.js"}; !reflect.DeepEqual(want, page.Entries) {
+		t.Errorf("expected entries %v, got %v", want, page.Entries)
+	}
+	if want := map[string]any{"name": "Oliver"}; !reflect.DeepEqual(want, page.Data) {
+		t.Errorf("expected data %v, got %v", want, page.Data)
+	}
+
+	// Browsers get the HTML.
+	req = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "<title>Profile</title>") || strings.HasPrefix(rec.Body.String(), "{") {
+		t.Errorf("expected HTML, got:\n%s", rec.Body.String())
+	}
+	if want, have := "Accept", rec.Header().Get("Vary"); want != have {
+		t.Errorf("expected Vary %q, got %q", want, have)
+	}
+}
+
+func TestHandlerRenderOptions(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:    getTestFS(),
+		IsDev: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/profile", `<html><head>{{ .Metadata }}{{ .Scripts }}</head><body>{{ .Data.Name }}</body></html>`)
+
+	ctx := vite.RenderOptionsToContext(context.Background(), vite.RenderOptions{
+		Metadata: &vite.Metadata{Title: "Profile"},
+		Data:     struct{ Name string }{"Alice"},
+	})
+	// The existing helpers set individual fields of the render options.
+	ctx = vite.ScriptsToContext(ctx, `<script>console.log("profile")</script>`)
+
+	opts := vite.RenderOptionsFromContext(ctx)
+	if opts.Metadata == nil || opts.Metadata.Title != "Profile" {
+		t.Fatalf("expected metadata to be kept, got %+v", opts.Metadata)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	for _, want := range []string{
+		"<title>Profile</title>",
+		`<script>console.log("profile")</script>`,
+		"<body>Alice</body>",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerRegisterTemplateFuncs(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:    getTestFS(),
+		IsDev: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplateFuncs(template.FuncMap{
+		"t": func(key string) string { return "Hallo" },
+	})
+	h.RegisterTemplate("/greeting", `<p>{{ t "hello" }}</p><a href="{{ urljoin "https://example.com" "docs" }}">`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/greeting", nil))
+	if want, have := `<p>Hallo</p><a href="https://example.com/docs">`, rec.Body.String(); want != have {
+		t.Fatalf("expected %q, got %q", want, have)
+	}
+
+	// Replace the function in templates registered before.
+	h.RegisterTemplateFuncs(template.FuncMap{
+		"t": func(key string) string { return "Hello" },
+	})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/greeting", nil))
+	if want := `<p>Hello</p>`; !strings.Contains(rec.Body.String(), want) {
+		t.Fatalf("expected body to contain %s, got %q", want, rec.Body.String())
+	}
+}
+
+func TestHandlerRegisterTemplatesFS(t *testing.T) {
+	templates := fstest.MapFS{
+		"index.html":      &fstest.MapFile{Data: []byte(`<p>Home</p>`)},
+		"about.html":      &fstest.MapFile{Data: []byte(`<p>About</p>`)},
+		"docs/index.html": &fstest.MapFile{Data: []byte(`<p>Docs</p>`)},
+		"docs/intro.tmpl": &fstest.MapFile{Data: []byte(`<p>Intro</p>`)},
+		"README.md":       &fstest.MapFile{Data: []byte(`# Templates`)},
+	}
+
+	h, err := vite.NewHandlerWithOptions(getTestFS(),
+		vite.WithTemplateFS(templates, "*.html", "docs/*"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"/":           "<p>Home</p>",
+		"/about":      "<p>About</p>",
+		"/docs":       "<p>Docs</p>",
+		"/docs/intro": "<p>Intro</p>",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if have := rec.Body.String(); want != have {
+			t.Errorf("%s: expected %q, got %q", path, want, have)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/README", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a file not matching the patterns, got %d", http.StatusNotFound, rec.Code)
+	}
+
+	if err := h.RegisterTemplatesFS(templates, "about.html"); err == nil {
+		t.Error("expected an error for a template that is already registered")
+	}
+	broken := fstest.MapFS{"broken.html": &fstest.MapFile{Data: []byte(`{{ .Oops`)}}
+	if err := h.RegisterTemplatesFS(broken, "*.html"); err == nil {
+		t.Error("expected an error for a template that cannot be parsed")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, err := vite.NewHandler(vite.Config{FS: fstest.MapFS{}})
+	if !errors.Is(err, vite.ErrManifestNotFound) {
+		t.Errorf("expected ErrManifestNotFound, got %v", err)
+	}
+
+	_, err = vite.NewHandler(vite.Config{FS: fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{`)},
+	}})
+	if !errors.Is(err, vite.ErrManifestInvalid) {
+		t.Errorf("expected ErrManifestInvalid, got %v", err)
+	}
+
+	_, err = vite.HTMLFragment(vite.Config{FS: getTestFS(), ViteEntry: "src/missing.tsx"})
+	if !errors.Is(err, vite.ErrEntryNotFound) {
+		t.Errorf("expected ErrEntryNotFound, got %v", err)
+	}
+
+	h, err := vite.NewHandler(vite.Config{FS: getTestFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.RegisterTemplatesFS(fstest.MapFS{}, "*.html")
+	if !errors.Is(err, vite.ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = vite.WaitForViteServer(ctx, srv.URL)
+	if !errors.Is(err, vite.ErrViteUnreachable) {
+		t.Errorf("expected ErrViteUnreachable, got %v", err)
+	}
+}
+
+func TestHandlerReloadTemplates(t *testing.T) {
+	for _, isDev := range []bool{true, false} {
+		templates := fstest.MapFS{
+			"about.html": &fstest.MapFile{Data: []byte(`<p>v1</p>`)},
+		}
+		h, err := vite.NewHandlerWithOptions(getTestFS(),
+			vite.WithDev(isDev),
+			vite.WithTemplateFS(templates),
+			vite.WithReloadTemplates(true),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		templates["about.html"] = &fstest.MapFile{Data: []byte(`<p>v2</p>`)}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/about", nil))
+		want := "<p>v1</p>"
+		if isDev {
+			want = "<p>v2</p>"
+		}
+		if have := rec.Body.String(); want != have {
+			t.Errorf("isDev=%v: expected %q, got %q", isDev, want, have)
+		}
+
+		// A broken template falls back to the one parsed on registration.
+		templates["about.html"] = &fstest.MapFile{Data: []byte(`{{ .Oops`)}
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/about", nil))
+		if have := rec.Body.String(); have != "<p>v1</p>" {
+			t.Errorf("isDev=%v: expected the registered template, got %q", isDev, have)
+		}
+	}
+}
+
+func TestHandlerReloadTemplatesOncePerRequest(t *testing.T) {
+	templates := &countingFS{FS: fstest.MapFS{
+		"about.html": &fstest.MapFile{Data: []byte(`<p>about</p>`)},
+	}}
+	h, err := vite.NewHandlerWithOptions(getTestFS(),
+		vite.WithDev(true),
+		vite.WithTemplateFS(templates),
+		vite.WithReloadTemplates(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templates.opens = 0
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/about", nil))
+	if have := rec.Body.String(); have != "<p>about</p>" {
+		t.Fatalf("expected the template, got %q", have)
+	}
+	if templates.opens != 1 {
+		t.Fatalf("expected the template to be read once per request, got %d reads", templates.opens)
+	}
+}
+
+// countingFS counts the files opened in FS.
+type countingFS struct {
+	fs.FS
+	opens int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	if name != "." {
+		c.opens++
+	}
+	return c.FS.Open(name)
+}
+
+func TestHandlerClientHelper(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:      getTestFS(),
+		IsDev:   true,
+		ViteURL: "http://localhost:5173",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/env", `{{ viteEnv .Data }}{{ .Scripts }}`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, vite.ClientPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if want, have := "text/javascript; charset=utf-8", rec.Header().Get("Content-Type"); want != have {
+		t.Errorf("expected Content-Type %q, got %q", want, have)
+	}
+	if want := `from "http://localhost:5173/@vite/client"`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected client to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	ctx := vite.RenderOptionsToContext(context.Background(), vite.RenderOptions{
+		Data:  map[string]string{"api": "</script>"},
+		Nonce: "abc",
+	})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/env", nil).WithContext(ctx))
+	want := `<script type="application/json" id="__vite_go_env">{"api":"\u003c/script\u003e"}</script>` +
+		`<script type="module" src="/__vite_go/client.js" nonce="abc"></script>`
+	if have := rec.Body.String(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+
+	// The client helper is only served in development mode.
+	h, err = vite.NewHandler(vite.Config{FS: getTestFS()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, vite.ClientPath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status %d in production mode, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+type tsUser struct {
+	Name    string    `json:"name"`
+	Manager *tsUser   `json:"manager"`
+	Joined  time.Time `json:"joined"`
+}
+
+type tsBase struct {
+	Version string `json:"version"`
+}
+
+type tsSettings struct {
+	tsBase
+	API      string            `json:"api"`
+	Features []string          `json:"features,omitempty"`
+	Limits   map[string]int    `json:"limits"`
+	User     tsUser            `json:"user"`
+	Count    int64             `json:"count,string"`
+	Secret   string            `json:"-"`
+	Dashed   bool              `json:"is-beta"`
+	Raw      json.RawMessage   `json:"raw"`
+	Extra    struct{ A bool }  `json:"extra"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	hidden   string
+}
+
+func TestWriteTypeScriptDefinitions(t *testing.T) {
+	var sb strings.Builder
+	if err := vite.WriteTypeScriptDefinitions(&sb, &tsSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by vite.WriteTypeScriptDefinitions. DO NOT EDIT.
+
+interface tsSettings {
+  version: string;
+  api: string;
+  features?: string[] | null;
+  limits: Record<string, number> | null;
+  user: tsUser;
+  count: string;
+  "is-beta": boolean;
+  raw: unknown;
+  extra: { A: boolean };
+  labels?: Record<string, string> | null;
+}
+
+interface tsUser {
+  name: string;
+  manager: tsUser | null;
+  joined: string;
+}
+
+declare module "/__vite_go/client.js" {
+  export function onFullReload(cb: (payload: unknown) => void): void;
+  export function showErrorOverlay(err: string | { message: string; stack?: string }): void;
+  export function readEnv(id?: string): tsSettings;
+}
+
+interface Window {
+  __vite_go: typeof import("/__vite_go/client.js");
+}
+`
+	if have := sb.String(); want != have {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, have)
+	}
+
+	if err := vite.WriteTypeScriptDefinitions(&sb, nil); err == nil {
+		t.Error("expected an error for nil")
+	}
+}
+
+func TestHandlerNotFoundAndErrorHandler(t *testing.T) {
+	var handledErr error
+	h, err := vite.NewHandlerWithOptions(getTestFS(),
+		vite.WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "<h1>%s not found</h1>", r.URL.Path)
+		})),
+		vite.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			handledErr = err
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "<h1>Oops</h1>")
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/broken", `{{ .Missing.Field }}`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nope.js", nil))
+	if want, have := "<h1>/nope.js not found</h1>", rec.Body.String(); rec.Code != http.StatusNotFound || want != have {
+		t.Errorf("expected %d %q, got %d %q", http.StatusNotFound, want, rec.Code, have)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	if want, have := "<h1>Oops</h1>", rec.Body.String(); rec.Code != http.StatusInternalServerError || want != have {
+		t.Errorf("expected %d %q, got %d %q", http.StatusInternalServerError, want, rec.Code, have)
+	}
+	if handledErr == nil {
+		t.Error("expected the error handler to receive the error")
+	}
+
+	ctx := vite.EntriesToContext(context.Background(), "src/missing.tsx")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if !errors.Is(handledErr, vite.ErrEntryNotFound) {
+		t.Errorf("expected ErrEntryNotFound, got %v", handledErr)
+	}
+}
+
+func TestHandlerRoutes(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/pages/index.tsx": {"file": "assets/index-1.js", "src": "src/pages/index.tsx", "isEntry": true},
+			"src/pages/blog/[slug].tsx": {"file": "assets/slug-2.js", "src": "src/pages/blog/[slug].tsx", "isEntry": true},
+			"src/pages/docs/[...rest].tsx": {"file": "assets/docs-3.js", "src": "src/pages/docs/[...rest].tsx", "isEntry": true}
+		}`)},
+		".vite/routes.json": &fstest.MapFile{Data: []byte(`[
+			{"path": "/", "entry": "src/pages/index.tsx"},
+			{"path": "/blog/:slug", "entry": "src/pages/blog/[slug].tsx"},
+			{"path": "/docs/*", "entry": "src/pages/docs/[...rest].tsx"}
+		]`)},
+		"docs/logo.png": &fstest.MapFile{Data: []byte("png")},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys, ViteRoutes: ".vite/routes.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		"/":               `src="/assets/index-1.js"`,
+		"/blog/hello":     `src="/assets/slug-2.js"`,
+		"/docs/a/b/c":     `src="/assets/docs-3.js"`,
+		"/docs/logo.png":  "png",
+		"/blog/hello/foo": "404 page not found",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("%s: expected body to contain %s, got:\n%s", path, want, rec.Body.String())
+		}
+	}
+
+	fsys[".vite/routes.json"] = &fstest.MapFile{Data: []byte(`[
+		{"path": "/blog/:slug", "entry": "a.tsx"},
+		{"path": "/blog/:id", "entry": "b.tsx"}
+	]`)}
+	if _, err := vite.NewHandler(vite.Config{FS: fsys, ViteRoutes: ".vite/routes.json"}); err == nil {
+		t.Error("expected an error for conflicting routes")
+	}
+}
+
+func TestHandlerLogger(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h, err := vite.NewHandlerWithOptions(getTestFS(), vite.WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/about", `<p>About</p>`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for _, want := range []string{
+		`msg="Loaded manifest"`,
+		`msg="Resolved entry points"`,
+		`msg="Template not found" requestedTemplate=index.html`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected log to contain %s, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestHandlerMetrics(t *testing.T) {
+	fsys := getTestFS().(fstest.MapFS)
+	fsys["assets/app.js"] = &fstest.MapFile{Data: []byte("app")}
+	h, err := vite.NewHandler(vite.Config{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/about", `<p>About</p>`)
+
+	for _, path := range []string{"/", "/about", "/assets/app.js", "/assets/missing.js"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var metrics struct {
+		PagesRendered  int `json:"pages_rendered"`
+		RenderErrors   int `json:"render_errors"`
+		TemplateHits   int `json:"template_hits"`
+		TemplateMisses int `json:"template_misses"`
+		TemplateExact  int `json:"template_exact_matches"`
+		TemplateFuzzy  int `json:"template_variation_matches"`
+		AssetRequests  int `json:"asset_requests"`
+		NotFound       int `json:"not_found"`
+		RenderLatency  struct {
+			Count   int            `json:"count"`
+			Buckets map[string]int `json:"buckets"`
+		} `json:"render_latency_seconds"`
+	}
+	if err := json.Unmarshal([]byte(h.Metrics().String()), &metrics); err != nil {
+		t.Fatalf("unable to decode metrics %s: %v", h.Metrics().String(), err)
+	}
+	if metrics.PagesRendered != 2 || metrics.RenderErrors != 0 {
+		t.Errorf("expected 2 pages and no errors, got %+v", metrics)
+	}
+	if metrics.TemplateHits != 1 || metrics.TemplateMisses != 1 {
+		t.Errorf("expected 1 template hit and miss, got %+v", metrics)
+	}
+	if metrics.TemplateExact != 1 || metrics.TemplateFuzzy != 0 {
+		t.Errorf("expected 1 exact match, got %+v", metrics)
+	}
+	if metrics.AssetRequests != 1 || metrics.NotFound != 1 {
+		t.Errorf("expected 1 asset request and 1 not found, got %+v", metrics)
+	}
+	if metrics.RenderLatency.Count != 2 || metrics.RenderLatency.Buckets["+Inf"] != 2 {
+		t.Errorf("expected 2 render latency observations, got %+v", metrics.RenderLatency)
+	}
+
+	// The index page is found by a variation of "index.html".
+	h, err = vite.NewHandler(vite.Config{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("index", `<p>Home</p>`)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if want, have := "1", h.Metrics().Get("template_variation_matches").String(); want != have {
+		t.Errorf("expected %s variation match, got %s", want, have)
+	}
+}
+
+func TestHandlerServesAssetsManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.tsx": {"file": "assets/main-4f2e1a.js", "src": "src/main.tsx", "isEntry": true, "css": ["assets/main-9b8c7d.css"]}
+		}`)},
+		"assets/main-4f2e1a.js":  &fstest.MapFile{Data: []byte("console.log(1)")},
+		"assets/main-9b8c7d.css": &fstest.MapFile{Data: []byte("body{}")},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys, ServeAssetsManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, vite.AssetsManifestPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	var body struct {
+		Files []vite.AssetInfo `json:"files"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Files) != 2 {
+		t.Fatalf("expected 2 files, got %+v", body.Files)
+	}
+	js := body.Files[0]
+	if want, have := "/assets/main-4f2e1a.js", js.URL; want != have {
+		t.Errorf("expected URL %q, got %q", want, have)
+	}
+	if want, have := int64(len("console.log(1)")), js.Size; want != have {
+		t.Errorf("expected size %d, got %d", want, have)
+	}
+	if want := "sha384-"; !strings.HasPrefix(js.Integrity, want) || len(js.Integrity) != len(want)+64 {
+		t.Errorf("expected a sha384 integrity hash, got %q", js.Integrity)
+	}
+
+	// Polling clients get a 304 until the build changes.
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+	req := httptest.NewRequest(http.MethodGet, vite.AssetsManifestPath, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected status %d, got %d", http.StatusNotModified, rec.Code)
+	}
+	original := fsys[".vite/manifest.json"]
+	fsys[".vite/manifest.json"] = &fstest.MapFile{Data: []byte(`{
+		"src/main.tsx": {"file": "assets/main-4f2e1a.js", "src": "src/main.tsx", "isEntry": true}
+	}`)}
+	if err := h.ReloadManifest(); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("expected status %d with a new ETag, got %d and %s", http.StatusOK, rec.Code, rec.Header().Get("ETag"))
+	}
+	fsys[".vite/manifest.json"] = original
+
+	// A file of the manifest that does not exist is an error.
+	m, err := vite.ParseManifest(strings.NewReader(string(fsys[".vite/manifest.json"].Data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(fsys, "assets/main-9b8c7d.css")
+	if _, err := m.AssetInfos(fsys, "/"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestHandlerCacheControl(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.tsx": {"file": "assets/main-4f2e1a9b.js", "src": "src/main.tsx", "isEntry": true, "imports": ["_vendor-7c3d2e1f.js"], "css": ["assets/main-9b8c7d6e.css"]},
+			"_vendor-7c3d2e1f.js": {"file": "assets/vendor-7c3d2e1f.js", "name": "vendor"},
+			"src/logo.svg": {"file": "assets/logo.svg", "src": "src/logo.svg"}
+		}`)},
+		"assets/main-4f2e1a9b.js":   &fstest.MapFile{Data: []byte("console.log(1)")},
+		"assets/main-9b8c7d6e.css":  &fstest.MapFile{Data: []byte("body{}")},
+		"assets/vendor-7c3d2e1f.js": &fstest.MapFile{Data: []byte("export{}")},
+		"assets/logo.svg":           &fstest.MapFile{Data: []byte("<svg/>")},
+		"robots.txt":                &fstest.MapFile{Data: []byte("User-agent: *")},
+	}
+
+	m, err := vite.ParseManifest(strings.NewReader(string(fsys[".vite/manifest.json"].Data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	classes := map[string]vite.AssetClass{}
+	for _, advice := range m.CacheAdvice("/") {
+		classes[advice.URL] = advice.Class
+	}
+	wantClasses := map[string]vite.AssetClass{
+		"/assets/main-4f2e1a9b.js":   vite.AssetApp,
+		"/assets/main-9b8c7d6e.css":  vite.AssetApp,
+		"/assets/vendor-7c3d2e1f.js": vite.AssetVendor,
+		"/assets/logo.svg":           vite.AssetUnhashed,
+	}
+	if !reflect.DeepEqual(wantClasses, classes) {
+		t.Errorf("expected classes %v, got %v", wantClasses, classes)
+	}
+
+	h, err := vite.NewHandler(vite.Config{FS: fsys, CacheControl: vite.DefaultCacheControl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/assets/vendor-7c3d2e1f.js", "public, max-age=31536000, immutable"},
+		{"/assets/main-9b8c7d6e.css", "public, max-age=31536000, immutable"},
+		{"/assets/logo.svg", "no-cache"},
+		{"/robots.txt", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", tt.path, http.StatusOK, rec.Code)
+		}
+		if have := rec.Header().Get("Cache-Control"); tt.want != have {
+			t.Errorf("%s: expected Cache-Control %q, got %q", tt.path, tt.want, have)
+		}
+	}
+}
+
+func TestHandlerResourceHints(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.tsx": {"file": "assets/main-4f2e1a9b.js", "src": "src/main.tsx", "isEntry": true}
+		}`)},
+	}
+	const hint = `<link rel="preconnect" href="https://cdn.example.com" crossorigin><link rel="dns-prefetch" href="https://cdn.example.com">`
+
+	tests := []struct {
+		name   string
+		config vite.Config
+		want   bool
+	}{
+		{"CDN", vite.Config{FS: fsys, Base: "https://cdn.example.com/app/"}, true},
+		{"ProtocolRelativeCDN", vite.Config{FS: fsys, Base: "//cdn.example.com/app/"}, true},
+		{"SameOrigin", vite.Config{FS: fsys, Base: "/app/"}, false},
+		{"Disabled", vite.Config{FS: fsys, Base: "https://cdn.example.com/app/", DisableResourceHints: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := vite.NewHandler(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "https://example.com/app/", nil)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+			}
+			if have := strings.Contains(rec.Body.String(), hint); tt.want != have {
+				t.Errorf("expected resource hints %v, got body %s", tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerModulePlacement(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.tsx": {"file": "assets/main-4f2e1a9b.js", "src": "src/main.tsx", "isEntry": true, "css": ["assets/main-9b8c7d6e.css"]}
+		}`)},
+	}
+	tests := []struct {
+		name   string
+		config vite.Config
+		head   string
+		body   string
+	}{
+		{
+			name:   "Production",
+			config: vite.Config{FS: fsys, ModulePlacement: vite.PlaceAtBodyEnd},
+			head:   `<link rel="stylesheet" href="/assets/main-9b8c7d6e.css">`,
+			body:   `<script type="module" src="/assets/main-4f2e1a9b.js"></script>`,
+		},
+		{
+			name:   "Development",
+			config: vite.Config{FS: fstest.MapFS{}, IsDev: true, ViteEntry: "src/main.tsx", ViteTemplate: vite.React, ModulePlacement: vite.PlaceAtBodyEnd},
+			head:   `/@react-refresh`,
+			body:   `<script type="module" src="http://localhost:5173/@vite/client"></script><script type="module" src="http://localhost:5173/src/main.tsx"></script>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := vite.NewHandler(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+			}
+			head, body, ok := strings.Cut(rec.Body.String(), "</head>")
+			if !ok {
+				t.Fatalf("expected a head element, got %s", rec.Body.String())
+			}
+			if !strings.Contains(head, tt.head) {
+				t.Errorf("expected %s in the head, got %s", tt.head, head)
+			}
+			if strings.Contains(head, "main-4f2e1a9b.js\"></script>") || strings.Contains(head, "src/main.tsx") {
+				t.Errorf("expected no entry point scripts in the head, got %s", head)
+			}
+			if !strings.Contains(body, tt.body) {
+				t.Errorf("expected %s in the body, got %s", tt.body, body)
+			}
+		})
+	}
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...vite.SpanAttribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+type testTracer struct{ spans []*testSpan }
+
+func (t *testTracer) StartSpan(ctx context.Context, name string, attrs ...vite.SpanAttribute) (context.Context, vite.Span) {
+	span := &testSpan{name: name, attrs: make(map[string]string)}
+	span.SetAttributes(attrs...)
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestHandlerTracer(t *testing.T) {
+	tracer := &testTracer{}
+	h, err := vite.NewHandler(vite.Config{FS: getTestFS(), ViteEntry: "views/foo.js", Tracer: tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(tracer.spans))
+	}
+	render, lookup := tracer.spans[0], tracer.spans[1]
+	if render.name != "vite.render_page" || !render.ended {
+		t.Errorf("expected an ended render span, got %+v", render)
+	}
+	if want, have := "views/foo.js", render.attrs["vite.entry"]; want != have {
+		t.Errorf("expected entry %q, got %q", want, have)
+	}
+	if want, have := "index.html", render.attrs["vite.template"]; want != have {
+		t.Errorf("expected template %q, got %q", want, have)
+	}
+	if lookup.name != "vite.manifest_lookup" || !lookup.ended || lookup.attrs["vite.entries"] != "views/foo.js" {
+		t.Errorf("expected an ended manifest lookup span, got %+v", lookup)
+	}
+
+	tracer.spans = nil
+	ctx := vite.EntriesToContext(context.Background(), "src/missing.tsx")
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	for _, span := range tracer.spans {
+		if !errors.Is(span.err, vite.ErrEntryNotFound) {
+			t.Errorf("expected span %s to record ErrEntryNotFound, got %v", span.name, span.err)
+		}
+	}
+}
+
+func TestHandlerScriptAttributes(t *testing.T) {
+	attrs := map[string]vite.ScriptAttributes{
+		"views/foo.js": {"async": "", "data-loader": `a"b`},
+	}
+
+	h, err := vite.NewHandler(vite.Config{FS: getTestFS(), ViteEntry: "views/foo.js", ScriptAttributes: attrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `.js" async data-loader="a&#34;b"></script>`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	h, err = vite.NewHandler(vite.Config{FS: getTestFS(), IsDev: true, ViteURL: "http://localhost:5173", ViteEntry: "views/foo.js", ScriptAttributes: attrs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `<script type="module" src="http://localhost:5173/views/foo.js" async data-loader="a&#34;b"></script>`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+}
+
+// discardResponseWriter is a response writer that discards the body, and
+// implements io.ReaderFrom like the response writer of net/http.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+func (w *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(io.Discard, r)
+}
+
+func BenchmarkHandlerServeLargeAsset(b *testing.B) {
+	const size = 10 << 20
+	fsys := getTestFS().(fstest.MapFS)
+	fsys["assets/app.wasm"] = &fstest.MapFile{Data: make([]byte, size)}
+	h, err := vite.NewHandler(vite.Config{FS: fsys})
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.wasm", nil)
+
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		h.ServeHTTP(&discardResponseWriter{header: make(http.Header)}, req)
+	}
+}
+
+type testRequestRenderer struct {
+	req vite.SSRRequest
+}
+
+func (rr *testRequestRenderer) Render(ctx context.Context, url string) (string, error) {
+	return "", errors.New("expected RenderRequest to be called")
+}
+
+func (rr *testRequestRenderer) RenderRequest(ctx context.Context, req vite.SSRRequest) (*vite.SSRResult, error) {
+	rr.req = req
+	return &vite.SSRResult{
+		HTML: "<p>hello " + req.Cookies["user"] + "</p>",
+		Headers: http.Header{
+			"Set-Cookie":     {"seen=1; Path=/"},
+			"Content-Length": {"1"},
+		},
+	}, nil
+}
+
+func TestHandlerPassesRequestSnapshotToSSR(t *testing.T) {
+	rr := &testRequestRenderer{}
+	h, err := vite.NewHandler(vite.Config{
+		FS:         getTestFS(),
+		SSR:        rr,
+		SSRHeaders: []string{"accept-language"},
+		SSRCookies: []string{"user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/?tab=1", nil)
+	req.Header.Set("Accept-Language", "de")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.AddCookie(&http.Cookie{Name: "user", Value: "alice"})
+	req.AddCookie(&http.Cookie{Name: "token", Value: "secret"})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	want := vite.SSRRequest{
+		URL:     "/?tab=1",
+		Method:  http.MethodGet,
+		Headers: map[string]string{"Accept-Language": "de"},
+		Cookies: map[string]string{"user": "alice"},
+	}
+	if !reflect.DeepEqual(want, rr.req) {
+		t.Errorf("expected request snapshot %+v, got %+v", want, rr.req)
+	}
+	if want := `<div id="root"><p>hello alice</p></div>`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected body to contain %s, got:\n%s", want, rec.Body.String())
+	}
+	if want, have := "seen=1; Path=/", rec.Header().Get("Set-Cookie"); want != have {
+		t.Errorf("expected Set-Cookie %q, got %q", want, have)
+	}
+	if have := rec.Header().Get("Content-Length"); have == "1" {
+		t.Error("expected Content-Length of the renderer to be ignored")
+	}
+}
+
+func TestHandlerTemplateChain(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(exampleManifest)},
+		"admin/logo.png":      &fstest.MapFile{Data: []byte("png")},
+		".vite/routes.json":   &fstest.MapFile{Data: []byte(`[{"path": "/blog/:slug", "entry": "views/bar.js"}]`)},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys, ViteEntry: "views/foo.js", ViteRoutes: ".vite/routes.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("index.html", "index")
+	h.RegisterTemplate("/admin", "admin")
+	h.RegisterTemplate("admin/users.html", "users")
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/", http.StatusOK, "index"},
+		{"/admin", http.StatusOK, "admin"},
+		{"/admin/users", http.StatusOK, "users"},
+		{"/admin/users/42", http.StatusOK, "users"},
+		{"/admin/settings", http.StatusOK, "admin"},
+		{"/admin/logo.png", http.StatusOK, "png"},
+		{"/blog/hello", http.StatusOK, "index"},
+		{"/missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+			continue
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.path, tt.body, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerCustomTemplateChain(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:        getTestFS(),
+		ViteEntry: "views/foo.js",
+		TemplateChain: func(path string) []string {
+			return []string{"layout"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("layout", "layout")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/anything", nil))
+	if want, have := "layout", rec.Body.String(); rec.Code != http.StatusOK || want != have {
+		t.Errorf("expected status 200 and body %q, got %d and %q", want, rec.Code, have)
+	}
+
+	if want, have := []string{"/a/b", "/a", "index.html"}, vite.DefaultTemplateChain("/a/b/"); !reflect.DeepEqual(want, have) {
+		t.Errorf("expected chain %v, got %v", want, have)
+	}
+}
+
+// largeManifest returns a manifest with 5000 chunks: 500 page entry points,
+// each importing a chunk of its own and a few of 100 shared chunks.
+func largeManifest() vite.Manifest {
+	m := make(vite.Manifest, 5000)
+	for i := range 100 {
+		key := fmt.Sprintf("_shared-%03d.js", i)
+		m[key] = &vite.Chunk{File: fmt.Sprintf("assets/shared-%03d-1a2b3c4d.js", i)}
+	}
+	for i := range 500 {
+		src := fmt.Sprintf("src/pages/page%03d.tsx", i)
+		m[src] = &vite.Chunk{
+			File:    fmt.Sprintf("assets/page%03d-1a2b3c4d.js", i),
+			Name:    fmt.Sprintf("page%03d", i),
+			Src:     src,
+			IsEntry: true,
+			CSS:     []string{fmt.Sprintf("assets/page%03d-5e6f7a8b.css", i)},
+			Imports: []string{fmt.Sprintf("_shared-%03d.js", i%100), fmt.Sprintf("_shared-%03d.js", (i+1)%100)},
+		}
+	}
+	for i := range 4400 {
+		src := fmt.Sprintf("src/components/c%04d.tsx", i)
+		m[src] = &vite.Chunk{File: fmt.Sprintf("assets/c%04d-1a2b3c4d.js", i), Src: src, IsDynamicEntry: true}
+	}
+	return m
+}
+
+func BenchmarkHandlerLargeManifest(b *testing.B) {
+	m := largeManifest()
+	if len(m) != 5000 {
+		b.Fatalf("expected 5000 chunks, got %d", len(m))
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fstest.MapFS{}, Manifest: &m})
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(vite.EntryToContext(req.Context(), "page250"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+		}
+	}
+}
+
+func BenchmarkManifestGetEntryPoints(b *testing.B) {
+	m := largeManifest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if entries := m.GetEntryPoints(); len(entries) != 500 {
+			b.Fatalf("expected 500 entry points, got %d", len(entries))
+		}
+	}
+}
+
+func BenchmarkManifestLookups(b *testing.B) {
+	m := largeManifest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if chunk := m.GetEntryPoint(); chunk == nil {
+			b.Fatal("expected an entry point")
+		}
+		if _, ok := m.AssetURL("src/components/c4399.tsx"); !ok {
+			b.Fatal("expected an asset URL")
+		}
+		if _, ok := m.AssetURL("c4399.tsx"); !ok {
+			b.Fatal("expected an asset URL by file name")
+		}
+	}
+}
+
+func TestHandlerNoScriptHTML(t *testing.T) {
+	h, err := vite.NewHandler(vite.Config{
+		FS:           fstest.MapFS{},
+		IsDev:        true,
+		ViteTemplate: vite.None,
+		NoScriptHTML: `<p>This app requires JavaScript.</p>`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	_, body, _ := strings.Cut(rec.Body.String(), "<body")
+	if want := `<noscript><p>This app requires JavaScript.</p></noscript>`; !strings.Contains(body, want) {
+		t.Errorf("expected %s in the body, got:\n%s", want, rec.Body.String())
+	}
+
+	h, err = vite.NewHandler(vite.Config{FS: fstest.MapFS{}, IsDev: true, ViteTemplate: vite.None})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), "<noscript>") {
+		t.Errorf("expected no noscript element, got:\n%s", rec.Body.String())
+	}
+}
+
+func TestHandlerAssetBudget(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.tsx": {"file": "assets/main-4f2e1a.js", "src": "src/main.tsx", "isEntry": true, "imports": ["_vendor.js"], "dynamicImports": ["src/lazy.tsx"], "css": ["assets/main-9b8c7d.css"]},
+			"_vendor.js": {"file": "assets/vendor-1a2b3c.js"},
+			"src/lazy.tsx": {"file": "assets/lazy-5d6e7f.js", "src": "src/lazy.tsx", "isDynamicEntry": true}
+		}`)},
+		"assets/main-4f2e1a.js":   &fstest.MapFile{Data: make([]byte, 100)},
+		"assets/vendor-1a2b3c.js": &fstest.MapFile{Data: make([]byte, 1000)},
+		"assets/lazy-5d6e7f.js":   &fstest.MapFile{Data: make([]byte, 5000)},
+		"assets/main-9b8c7d.css":  &fstest.MapFile{Data: make([]byte, 20)},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("index.html", `{{ .Budget.JS }} {{ .Budget.CSS }} {{ .Budget.Total }}{{ if gt .Budget.JS 1000 }} heavy{{ end }}`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want, have := "1100 20 1120 heavy", rec.Body.String(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+}
+
+func TestHandlerServeIndexHTML(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"index.html": {"file": "assets/index-4f2e1a9b.js", "src": "index.html", "isEntry": true}
+		}`)},
+		"index.html": &fstest.MapFile{Data: []byte(`<!doctype html>
+<html>
+  <head>
+    <title>Vite App</title>
+    <script type="module" crossorigin src="/assets/index-4f2e1a9b.js"></script>
+  </head>
+  <body>
+    <div id="root"></div>
+  </body>
+</html>
+`)},
+	}
+	h, err := vite.NewHandler(vite.Config{FS: fsys, ServeIndexHTML: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetDefaultMetadata(&vite.Metadata{Title: "Welcome", Description: "Hello"})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`<script type="module" crossorigin src="/assets/index-4f2e1a9b.js"></script>`,
+		"<title>Welcome</title>",
+		`<meta name="description" content="Hello" />`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %s, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Vite App") {
+		t.Errorf("expected the title of the page to be replaced, got:\n%s", body)
+	}
+
+	// Metadata without a title keeps the title of the page.
+	h.SetDefaultMetadata(&vite.Metadata{Description: "Hello"})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body = rec.Body.String()
+	if want := "<title>Vite App</title>"; !strings.Contains(body, want) || strings.Count(body, "<title>") != 1 {
+		t.Errorf("expected page to contain %s as the only title, got:\n%s", want, body)
+	}
+	if want := `<meta name="description" content="Hello" />`; !strings.Contains(body, want) {
+		t.Errorf("expected page to contain %s, got:\n%s", want, body)
+	}
+
+	// The base URL applies to the scripts already in the page.
+	h.SetDefaultMetadata(&vite.Metadata{Base: "/app/"})
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body = rec.Body.String()
+	base, script := strings.Index(body, `<base href="/app/" />`), strings.Index(body, `<script type="module"`)
+	if base < 0 || base > script || strings.Count(body, "<base") != 1 {
+		t.Errorf("expected a single base element before the module script, got:\n%s", body)
+	}
+
+	h.RegisterTemplate("index.html", `<p>{{ .Modules }}</p>`)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `<p><script type="module" src="/assets/index-4f2e1a9b.js"></script></p>`; rec.Body.String() != want {
+		t.Errorf("expected registered template %s, got %s", want, rec.Body.String())
+	}
+}
+
+func TestHandlerServeIndexHTMLWithSSR(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"index.html": {"file": "assets/index-4f2e1a9b.js", "src": "index.html", "isEntry": true}
+		}`)},
+		"index.html": &fstest.MapFile{Data: []byte(`<!doctype html>
+<html>
+  <head>
+    <title>Vite App</title>
+  </head>
+  <body>
+    <div class="app-shell" ID='app'>
+    </div>
+  </body>
+</html>
+`)},
+	}
+	h, err := vite.NewHandler(vite.Config{
+		FS:             fsys,
+		ServeIndexHTML: true,
+		SSR: vite.SSRRendererFunc(func(ctx context.Context, url string) (string, error) {
+			return "<h1>Hello from SSR</h1>", nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `<div class="app-shell" ID='app'><h1>Hello from SSR</h1></div>`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected page to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	// The page is read once per manifest.
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`<p>changed</p>`)}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rec.Body.String(), "changed") {
+		t.Errorf("expected the index.html to be cached, got:\n%s", rec.Body.String())
+	}
+}
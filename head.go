@@ -0,0 +1,145 @@
+package vite
+
+import (
+	"html/template"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// HeadParts are the parts of the <head> element of a page, as returned by
+// [RenderHead]. Empty parts are not needed for the configuration.
+type HeadParts struct {
+	// Metadata are the title, meta, and link tags of the page metadata.
+	Metadata template.HTML
+	// PluginReactPreamble is the preamble for React Fast Refresh, in
+	// development mode.
+	PluginReactPreamble template.HTML
+	// StyleSheets are the stylesheet links of the entry points. In
+	// development mode, they link CSS-only entry points on the dev server.
+	StyleSheets template.HTML
+	// Modules are the module scripts of the entry points. In development
+	// mode, they load the Vite client and the entry points from the dev
+	// server.
+	Modules template.HTML
+	// PreloadModules are the modulepreload links of the entry points, in
+	// production mode.
+	PreloadModules template.HTML
+	// Scripts are the scripts of the render options.
+	Scripts template.HTML
+}
+
+// HTML returns all parts in the order of the default template.
+func (p HeadParts) HTML() template.HTML {
+	var sb strings.Builder
+	for _, part := range []template.HTML{p.Metadata, p.PluginReactPreamble, p.StyleSheets, p.Modules, p.PreloadModules, p.Scripts} {
+		if part != "" {
+			sb.WriteString(string(part))
+			sb.WriteByte('\n')
+		}
+	}
+	return template.HTML(sb.String())
+}
+
+// RenderHead renders the parts of the <head> element of a page for the
+// given configuration, without an HTTP request, e.g. to render pages from
+// a CLI, a message queue consumer, or a test. The metadata, scripts, and
+// entry points of opts are used as the handler uses them; Data and Nonce
+// are ignored.
+//
+// Like [HTMLFragment], it reads the manifest from FS in production mode on
+// every call, unless Manifest is set.
+func RenderHead(config Config, opts RenderOptions) (HeadParts, error) {
+	if entries := opts.entries(); len(entries) > 0 {
+		config.ViteEntry = entries[0]
+		config.ViteEntries = entries
+	}
+	pd, err := fragmentPageData(config)
+	if err != nil {
+		return HeadParts{}, err
+	}
+
+	parts := HeadParts{
+		PluginReactPreamble: pd.PluginReactPreamble,
+		StyleSheets:         pd.StyleSheets,
+		Modules:             pd.Modules,
+		PreloadModules:      pd.PreloadModules,
+		Scripts:             template.HTML(opts.Scripts),
+	}
+	if opts.Metadata != nil {
+		parts.Metadata = template.HTML(opts.Metadata.String())
+	}
+	if pd.IsDev {
+		parts.Modules = devModules(pd, config.ScriptAttributes)
+	}
+	return parts, nil
+}
+
+// devModules returns the module scripts that load the Vite client and the
+// entry points from the dev server.
+func devModules(pd *PageData, attrs map[string]ScriptAttributes) template.HTML {
+	entries := pd.ViteEntries
+	if len(entries) == 0 && pd.ViteEntry != "" {
+		entries = []string{pd.ViteEntry}
+	}
+
+	var sb strings.Builder
+	script := func(src string, attrs ScriptAttributes) {
+		sb.WriteString(`<script type="module" src="`)
+		sb.WriteString(template.HTMLEscapeString(src))
+		sb.WriteString(`"`)
+		sb.WriteString(string(attrs.html()))
+		sb.WriteString(`></script>`)
+	}
+	client, _ := url.JoinPath(pd.ViteURL, "@vite/client")
+	script(client, nil)
+	for _, entry := range entries {
+		src, _ := url.JoinPath(pd.ViteURL, entry)
+		script(src, attrs[strings.TrimPrefix(entry, "/")])
+	}
+	return template.HTML(sb.String())
+}
+
+// styleSheetExts are the extensions of CSS-only entry points, e.g.
+// "src/styles.css" with the Tailwind directives.
+var styleSheetExts = map[string]bool{
+	".css": true, ".scss": true, ".sass": true, ".less": true,
+	".styl": true, ".stylus": true, ".pcss": true, ".postcss": true,
+}
+
+// isStyleSheetEntry returns true if the entry point is a stylesheet.
+func isStyleSheetEntry(entry string) bool {
+	return styleSheetExts[strings.ToLower(path.Ext(entry))]
+}
+
+// devStyleSheets moves the CSS-only entry points of the page from the
+// entry points to stylesheet links to the dev server. Vite serves them as
+// CSS and updates them on changes, e.g. with the classes Tailwind finds
+// in templates, while module scripts would only apply them after the
+// scripts ran.
+func devStyleSheets(pd *PageData) {
+	entries := pd.ViteEntries
+	if len(entries) == 0 && pd.ViteEntry != "" {
+		entries = []string{pd.ViteEntry}
+	}
+	var scripts, urls []string
+	for _, entry := range entries {
+		if !isStyleSheetEntry(entry) {
+			scripts = append(scripts, entry)
+			continue
+		}
+		u, _ := url.JoinPath(pd.ViteURL, entry)
+		urls = append(urls, template.HTMLEscapeString(u))
+	}
+	if len(urls) == 0 {
+		return
+	}
+	pd.StyleSheets = template.HTML(cssTags(urls))
+	pd.ViteEntry = ""
+	if len(scripts) > 0 {
+		pd.ViteEntry = scripts[0]
+	}
+	if len(pd.ViteEntries) > 0 {
+		pd.ViteEntries = scripts
+	}
+}
@@ -1,8 +1,12 @@
 package vite_test
 
 import (
+	"errors"
 	"fmt"
+	"html/template"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"testing/fstest"
@@ -207,3 +211,373 @@ func TestDevModeFragmentWorksWithTrailingSlash(t *testing.T) {
 		t.Fatalf("Generated HTML block does not contain: %s", viteClientTag)
 	}
 }
+
+func TestRenderHead(t *testing.T) {
+	parts, err := vite.RenderHead(vite.Config{FS: getTestFS()}, vite.RenderOptions{
+		Metadata: &vite.Metadata{Title: "Foo"},
+		Scripts:  `<script>window.foo = 1</script>`,
+		Entry:    "views/foo.js",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<title>Foo</title>"; !strings.Contains(string(parts.Metadata), want) {
+		t.Errorf("expected metadata to contain %s, got %q", want, parts.Metadata)
+	}
+	if want := `<script type="module" src="/assets/foo-BRBmoGS9.js"></script>`; string(parts.Modules) != want {
+		t.Errorf("expected modules %q, got %q", want, parts.Modules)
+	}
+	if parts.StyleSheets == "" || parts.PreloadModules == "" {
+		t.Errorf("expected stylesheets and preloads, got %+v", parts)
+	}
+	if want := `<script>window.foo = 1</script>`; !strings.HasSuffix(strings.TrimSpace(string(parts.HTML())), want) {
+		t.Errorf("expected HTML to end with the scripts, got %q", parts.HTML())
+	}
+
+	parts, err = vite.RenderHead(vite.Config{IsDev: true, ViteTemplate: vite.Vue}, vite.RenderOptions{Entries: []string{"src/a.ts", "src/b.ts"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<script type="module" src="http://localhost:5173/@vite/client"></script>` +
+		`<script type="module" src="http://localhost:5173/src/a.ts"></script>` +
+		`<script type="module" src="http://localhost:5173/src/b.ts"></script>`
+	if string(parts.Modules) != want {
+		t.Errorf("expected modules %q, got %q", want, parts.Modules)
+	}
+
+	if _, err := vite.RenderHead(vite.Config{FS: getTestFS()}, vite.RenderOptions{Entry: "missing.js"}); !errors.Is(err, vite.ErrEntryNotFound) {
+		t.Errorf("expected ErrEntryNotFound, got %v", err)
+	}
+}
+
+func TestFragmentWithOptions(t *testing.T) {
+	fragment, err := vite.HTMLFragmentWithOptions(vite.Config{FS: getTestFS()}, vite.FragmentOptions{
+		Metadata: &vite.Metadata{Title: "Foo"},
+		Scripts:  `<script>window.foo = 1</script>`,
+		Entries:  []string{"views/foo.js"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := strings.TrimSpace(string(fragment.Tags))
+	if want := "<title>Foo</title>"; !strings.HasPrefix(html, want) {
+		t.Errorf("expected fragment to start with %s, got:\n%s", want, html)
+	}
+	if want := `<script type="module" src="/assets/foo-BRBmoGS9.js"></script>`; !strings.Contains(html, want) {
+		t.Errorf("expected fragment to contain %s, got:\n%s", want, html)
+	}
+	if want := `<script>window.foo = 1</script>`; !strings.HasSuffix(html, want) {
+		t.Errorf("expected fragment to end with %s, got:\n%s", want, html)
+	}
+}
+
+func TestFragmentParts(t *testing.T) {
+	fragment, err := vite.HTMLFragment(vite.Config{FS: getTestFS(), ViteEntry: "views/foo.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<link rel="stylesheet" href="/assets/foo-5UjPuW-k.css">`; !strings.Contains(string(fragment.StyleSheets), want) {
+		t.Errorf("expected stylesheets to contain %s, got %q", want, fragment.StyleSheets)
+	}
+	if want := `<script type="module" src="/assets/foo-BRBmoGS9.js"></script>`; string(fragment.Modules) != want {
+		t.Errorf("expected modules %s, got %q", want, fragment.Modules)
+	}
+	if want := `<link rel="modulepreload" href="/assets/shared-B7PI925R.js">`; !strings.Contains(string(fragment.PreloadModules), want) {
+		t.Errorf("expected preload modules to contain %s, got %q", want, fragment.PreloadModules)
+	}
+	if strings.Contains(string(fragment.StyleSheets), "<script") {
+		t.Errorf("expected no scripts in the stylesheets, got %q", fragment.StyleSheets)
+	}
+
+	fragment, err = vite.HTMLFragment(vite.Config{FS: getTestFS(), IsDev: true, ViteEntry: "src/main.tsx", ViteTemplate: vite.React})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/@react-refresh"; !strings.Contains(string(fragment.Preamble), want) {
+		t.Errorf("expected preamble to contain %s, got %q", want, fragment.Preamble)
+	}
+	if want := `<script type="module" src="http://localhost:5173/src/main.tsx"></script>`; !strings.Contains(string(fragment.Modules), want) {
+		t.Errorf("expected modules to contain %s, got %q", want, fragment.Modules)
+	}
+}
+
+func TestFragmentCache(t *testing.T) {
+	fsys := getTestFS().(fstest.MapFS)
+	cache, err := vite.NewFragmentCache(vite.Config{FS: fsys, ViteEntry: "views/foo.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The manifest is read once.
+	delete(fsys, ".vite/manifest.json")
+
+	first, err := cache.Fragment(vite.FragmentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cache.Fragment(vite.FragmentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the cached fragment")
+	}
+
+	bar, err := cache.Fragment(vite.FragmentOptions{Entries: []string{"views/bar.js"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bar == first || !strings.Contains(string(bar.Modules), "bar-") {
+		t.Errorf("expected a fragment for the entry, got %q", bar.Modules)
+	}
+
+	if _, err := vite.NewFragmentCache(vite.Config{FS: fsys}); !errors.Is(err, vite.ErrManifestNotFound) {
+		t.Errorf("expected ErrManifestNotFound, got %v", err)
+	}
+}
+
+func TestWriteHTMLFragment(t *testing.T) {
+	config := vite.Config{FS: getTestFS(), ViteEntry: "views/foo.js"}
+	fragment, err := vite.HTMLFragment(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := vite.WriteHTMLFragment(&sb, config); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := string(fragment.Tags), sb.String(); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+
+	if err := vite.WriteHTMLFragment(&sb, vite.Config{FS: fstest.MapFS{}}); !errors.Is(err, vite.ErrManifestNotFound) {
+		t.Errorf("expected ErrManifestNotFound, got %v", err)
+	}
+}
+
+func TestFragmentTemplate(t *testing.T) {
+	fragment, err := vite.HTMLFragment(vite.Config{
+		FS:               getTestFS(),
+		ViteEntry:        "views/foo.js",
+		FragmentTemplate: "{{ .Modules }}\n{{ .StyleSheets }}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<script type=\"module\" src=\"/assets/foo-BRBmoGS9.js\"></script>\n<link rel=\"stylesheet\""
+	if have := string(fragment.Tags); !strings.HasPrefix(have, want) || strings.Contains(have, "modulepreload") {
+		t.Errorf("expected tags of the custom template, got %q", have)
+	}
+
+	_, err = vite.HTMLFragment(vite.Config{FS: getTestFS(), FragmentTemplate: `{{ .Missing`})
+	if err == nil || !strings.Contains(err.Error(), "vite: parse template") {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
+
+func TestHTMLFragments(t *testing.T) {
+	fragment, err := vite.HTMLFragments(vite.Config{FS: getTestFS()}, "views/foo.js", "views/bar.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := string(fragment.Tags)
+	for _, tag := range []string{
+		`<script type="module" src="/assets/foo-BRBmoGS9.js"></script>`,
+		`<script type="module" src="/assets/bar-gkvgaI9m.js"></script>`,
+		`<link rel="stylesheet" href="/assets/shared-ChJ_j-JJ.css">`,
+		`<link rel="modulepreload" href="/assets/shared-B7PI925R.js">`,
+	} {
+		if n := strings.Count(tags, tag); n != 1 {
+			t.Errorf("expected fragment to contain %s once, got %d times:\n%s", tag, n, tags)
+		}
+	}
+
+	if _, err := vite.HTMLFragments(vite.Config{FS: getTestFS()}, "views/missing.js"); !errors.Is(err, vite.ErrEntryNotFound) {
+		t.Errorf("expected ErrEntryNotFound, got %v", err)
+	}
+}
+
+func TestFuncs(t *testing.T) {
+	funcs, err := vite.Funcs(vite.Config{FS: getTestFS(), ViteEntry: "views/foo.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New("page").Funcs(funcs).Parse(
+		`{{ viteCSS }}|{{ viteJS "views/bar.js" }}|{{ viteAsset "views/foo.js" }}|{{ viteAsset "logo.png" }}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(sb.String(), "|")
+	if len(parts) != 4 {
+		t.Fatalf("expected 4 parts, got %q", sb.String())
+	}
+	if css := parts[0]; !strings.Contains(css, "/assets/foo-5UjPuW-k.css") || strings.Contains(css, "<script") {
+		t.Errorf("expected viteCSS to return the stylesheets of foo, got %q", css)
+	}
+	if js := parts[1]; !strings.Contains(js, `src="/assets/bar-gkvgaI9m.js"`) || strings.Contains(js, "stylesheet") {
+		t.Errorf("expected viteJS to return the scripts of bar, got %q", js)
+	}
+	if want, have := "/assets/foo-BRBmoGS9.js", parts[2]; want != have {
+		t.Errorf("expected viteAsset to return %q, got %q", want, have)
+	}
+	if want, have := "/logo.png", parts[3]; want != have {
+		t.Errorf("expected viteAsset to return %q, got %q", want, have)
+	}
+
+	funcs, err = vite.Funcs(vite.Config{IsDev: true, ViteEntry: "src/main.tsx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := funcs["viteTags"].(func(...string) (template.HTML, error))()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tags), `src="http://localhost:5173/src/main.tsx"`) {
+		t.Errorf("expected viteTags to contain the dev entry script, got %q", tags)
+	}
+	if want, have := "http://localhost:5173/logo.png", funcs["viteAsset"].(func(string) string)("logo.png"); want != have {
+		t.Errorf("expected viteAsset to return %q, got %q", want, have)
+	}
+
+	if _, err := vite.Funcs(vite.Config{FS: fstest.MapFS{}}); err == nil {
+		t.Error("expected an error without manifest")
+	}
+}
+
+func TestFragmentWithoutViteTemplateHasNoPreamble(t *testing.T) {
+	fragment, err := vite.HTMLFragment(vite.Config{FS: getTestFS(), IsDev: true, ViteEntry: "src/main.ts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fragment.Preamble != "" || strings.Contains(string(fragment.Tags), "/@react-refresh") {
+		t.Errorf("expected no React preamble without ViteTemplate, got %q", fragment.Tags)
+	}
+}
+
+func TestDefaultEntry(t *testing.T) {
+	fragment, err := vite.HTMLFragment(vite.Config{FS: getTestFS(), IsDev: true, DefaultEntry: "src/main.ts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<script type="module" src="http://localhost:5173/src/main.ts"></script>`; !strings.Contains(string(fragment.Tags), want) {
+		t.Errorf("expected fragment to contain %s, got %q", want, fragment.Tags)
+	}
+
+	h, err := vite.NewHandler(vite.Config{FS: fstest.MapFS{}, IsDev: true, ViteTemplate: vite.Vue, DefaultEntry: "src/main.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if want := `<script type="module" src="http://localhost:5173/src/main.js"></script>`; !strings.Contains(body, want) {
+		t.Errorf("expected page to contain %s, got:\n%s", want, body)
+	}
+	if strings.Contains(body, "src/main.tsx") {
+		t.Errorf("expected no default React entry, got:\n%s", body)
+	}
+}
+
+func TestFragmentModulePlacement(t *testing.T) {
+	fragment, err := vite.HTMLFragment(vite.Config{FS: getTestFS(), ViteEntry: "views/foo.js", ModulePlacement: vite.PlaceAtBodyEnd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	module := `<script type="module" src="/assets/foo-BRBmoGS9.js"></script>`
+	if strings.Contains(string(fragment.Tags), module) {
+		t.Errorf("expected no module script in the tags, got %q", fragment.Tags)
+	}
+	if want := `<link rel="stylesheet" href="/assets/foo-5UjPuW-k.css">`; !strings.Contains(string(fragment.Tags), want) {
+		t.Errorf("expected tags to contain %s, got %q", want, fragment.Tags)
+	}
+	if string(fragment.BodyTags) != module || string(fragment.Modules) != module {
+		t.Errorf("expected body tags and modules %s, got %q and %q", module, fragment.BodyTags, fragment.Modules)
+	}
+
+	fragment, err = vite.HTMLFragment(vite.Config{FS: getTestFS(), IsDev: true, ViteEntry: "src/main.ts", ModulePlacement: vite.PlaceAtBodyEnd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(fragment.Tags), "<script") {
+		t.Errorf("expected no scripts in the tags, got %q", fragment.Tags)
+	}
+	if want := `<script type="module" src="http://localhost:5173/@vite/client"></script><script type="module" src="http://localhost:5173/src/main.ts"></script>`; string(fragment.BodyTags) != want {
+		t.Errorf("expected body tags %s, got %q", want, fragment.BodyTags)
+	}
+}
+
+func TestCSSOnlyEntry(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{Data: []byte(`{
+			"src/main.ts": {"file": "assets/main-1a2b3c.js", "name": "main", "src": "src/main.ts", "isEntry": true},
+			"src/styles.css": {"file": "assets/styles-4d5e6f.css", "src": "src/styles.css", "isEntry": true}
+		}`)},
+	}
+	entries := []string{"src/main.ts", "src/styles.css"}
+
+	fragment, err := vite.HTMLFragments(vite.Config{FS: fsys}, entries...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<link rel="stylesheet" href="/assets/styles-4d5e6f.css">`; string(fragment.StyleSheets) != want {
+		t.Errorf("expected stylesheets %s, got %q", want, fragment.StyleSheets)
+	}
+	if want := `<script type="module" src="/assets/main-1a2b3c.js"></script>`; string(fragment.Modules) != want {
+		t.Errorf("expected modules %s, got %q", want, fragment.Modules)
+	}
+	if strings.Contains(string(fragment.Tags), "styles-4d5e6f.css\"></script>") || strings.Contains(string(fragment.PreloadModules), ".css") {
+		t.Errorf("expected the stylesheet not to be loaded as a module, got %q", fragment.Tags)
+	}
+
+	fragment, err = vite.HTMLFragments(vite.Config{FS: fsys, IsDev: true}, entries...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<link rel="stylesheet" href="http://localhost:5173/src/styles.css">`; string(fragment.StyleSheets) != want || !strings.Contains(string(fragment.Tags), want) {
+		t.Errorf("expected stylesheets %s, got %q", want, fragment.Tags)
+	}
+	if want := `<script type="module" src="http://localhost:5173/src/main.ts"></script>`; !strings.Contains(string(fragment.Modules), want) {
+		t.Errorf("expected modules to contain %s, got %q", want, fragment.Modules)
+	}
+	if strings.Contains(string(fragment.Tags), `src="http://localhost:5173/src/styles.css"`) {
+		t.Errorf("expected the stylesheet not to be loaded as a module, got %q", fragment.Tags)
+	}
+
+	// The handler links the stylesheet in both modes.
+	h, err := vite.NewHandler(vite.Config{FS: fsys, ViteEntries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := `<link rel="stylesheet" href="/assets/styles-4d5e6f.css">`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("expected page to contain %s, got:\n%s", want, rec.Body.String())
+	}
+
+	h, err = vite.NewHandler(vite.Config{FS: fstest.MapFS{}, IsDev: true, ViteTemplate: vite.None, ViteEntries: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	if want := `<link rel="stylesheet" href="http://localhost:5173/src/styles.css">`; !strings.Contains(body, want) {
+		t.Errorf("expected page to contain %s, got:\n%s", want, body)
+	}
+	if strings.Contains(body, `src="http://localhost:5173/src/styles.css"`) {
+		t.Errorf("expected the stylesheet not to be loaded as a module, got:\n%s", body)
+	}
+
+	m, err := vite.ParseManifest(strings.NewReader(string(fsys[".vite/manifest.json"].Data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk := m.GetEntryPoint(); chunk == nil || chunk.IsCSS() {
+		t.Errorf("expected the script as entry point, got %+v", chunk)
+	}
+}
@@ -0,0 +1,57 @@
+package vite
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// resourceHints returns preconnect and dns-prefetch links for the origins
+// of the given URLs that differ from the origin of the request, e.g. for a
+// Base on a CDN or the Vite dev server. Relative URLs are skipped.
+func resourceHints(r *http.Request, urls ...string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, s := range urls {
+		u, err := url.Parse(s)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		if u.Scheme == "" {
+			u.Scheme = scheme
+		}
+		if u.Scheme == scheme && strings.EqualFold(u.Host, r.Host) {
+			continue
+		}
+		origin := u.Scheme + "://" + u.Host
+		if seen[origin] {
+			continue
+		}
+		seen[origin] = true
+
+		// Module scripts are fetched in CORS mode, so the preconnected
+		// connection must be one for CORS requests to be reused.
+		href := template.HTMLEscapeString(origin)
+		sb.WriteString(`<link rel="preconnect" href="`)
+		sb.WriteString(href)
+		sb.WriteString(`" crossorigin><link rel="dns-prefetch" href="`)
+		sb.WriteString(href)
+		sb.WriteString(`">`)
+	}
+	return sb.String()
+}
+
+// assetOrigins returns the URLs scripts and stylesheets of pages are loaded
+// from: the Vite dev server in development mode, the base otherwise.
+func (h *Handler) assetOrigins() []string {
+	if h.isDev {
+		return []string{h.viteURL}
+	}
+	return []string{h.base}
+}
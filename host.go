@@ -0,0 +1,151 @@
+package vite
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// handlerHosts holds the per-host views of a handler, by hostname.
+type handlerHosts struct {
+	mu sync.RWMutex
+	m  map[string]*Handler
+}
+
+// lookup returns the view for the host of a request, if any.
+func (hh *handlerHosts) lookup(host string) *Handler {
+	hh.mu.RLock()
+	defer hh.mu.RUnlock()
+	if len(hh.m) == 0 {
+		return nil
+	}
+	return hh.m[normalizeHost(host)]
+}
+
+// normalizeHost returns the lowercase hostname without the port.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// ForHost returns the view of the handler for requests to the given
+// hostname, e.g. "tenant.example.com", creating it on first use. The
+// handler serves requests for the hostname with the view.
+//
+// A view has its own template registry and default metadata, and falls
+// back to the templates and default metadata of the handler. Everything
+// else, including templates, functions, and default metadata set on the
+// handler later on, is used from the handler. This allows white-label
+// applications to serve customized pages per tenant from a single handler
+// and asset build. Calling ForHost on a view returns a view of the
+// original handler.
+func (h *Handler) ForHost(host string) *Handler {
+	root := h.root()
+	host = normalizeHost(host)
+
+	root.hosts.mu.Lock()
+	defer root.hosts.mu.Unlock()
+
+	if v, ok := root.hosts.m[host]; ok {
+		return v
+	}
+	// A view only holds its overrides; requests are served by the handler.
+	v := &Handler{
+		parent:    root,
+		templates: make(map[string]*template.Template),
+	}
+	root.hosts.m[host] = v
+	return v
+}
+
+// root returns the handler of a host view or group, or h itself.
+func (h *Handler) root() *Handler {
+	if h.parent != nil {
+		return h.parent
+	}
+	return h
+}
+
+// serveHost serves the request with the templates and default metadata of
+// the host view v, and everything else of h.
+func (h *Handler) serveHost(w http.ResponseWriter, r *http.Request, v *Handler) {
+	hv := *h
+	hv.host = v
+	hv.ServeHTTP(w, r)
+}
+
+// findTemplate returns the registered template with the given name,
+// preferring the templates of the host view serving the request. If
+// templates are reloaded, templates registered from files are parsed
+// again, so it is called once per request, with the template to render.
+// Use templateRegistered to check for a template.
+func (h *Handler) findTemplate(name string) (*template.Template, bool) {
+	templates, files := h.templates, h.templateFiles
+	if _, ok := h.hostTemplates()[name]; ok {
+		templates, files = h.host.templates, h.host.templateFiles
+	}
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, false
+	}
+	if src, ok := files[name]; ok && h.reloadTemplates {
+		reloaded, err := h.parseTemplateFile(name, src)
+		if err != nil {
+			h.logger.Warn("Unable to reload template", "template", name, "error", err)
+			return tmpl, true
+		}
+		return reloaded, true
+	}
+	return tmpl, true
+}
+
+// templateRegistered returns true if a template with the given name is
+// registered, including those of the host view serving the request. It
+// does not parse templates again.
+func (h *Handler) templateRegistered(name string) bool {
+	if _, ok := h.hostTemplates()[name]; ok {
+		return true
+	}
+	_, ok := h.templates[name]
+	return ok
+}
+
+// templateNames returns the names of the registered templates, including
+// those of the host view serving the request.
+func (h *Handler) templateNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, templates := range []map[string]*template.Template{h.hostTemplates(), h.templates} {
+		for name := range templates {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hostTemplates returns the templates of the host view serving the
+// request, if any.
+func (h *Handler) hostTemplates() map[string]*template.Template {
+	if h.host == nil {
+		return nil
+	}
+	return h.host.templates
+}
+
+// getDefaultMetadata returns the default metadata, preferring the default
+// metadata of the host view serving the request.
+func (h *Handler) getDefaultMetadata() *Metadata {
+	if h.host != nil && h.host.defaultMetadata != nil {
+		return h.host.defaultMetadata
+	}
+	return h.defaultMetadata
+}
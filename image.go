@@ -0,0 +1,51 @@
+package vite
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// imageVariant is a resized variant of an image.
+type imageVariant struct {
+	url   string
+	width int
+}
+
+// PreloadImage returns a preload link for the image src, e.g. the hero
+// image that is the Largest Contentful Paint of a page, so the browser
+// fetches it with high priority before it discovers the image in the page.
+// Templates can use it as {{ preloadImage "src/assets/hero.png" }}.
+//
+// If the manifest contains resized variants of the image, as generated by
+// vite-imagetools (e.g. "src/assets/hero.png?w=800"), they are added as
+// imagesrcset, with the given sizes or "100vw".
+func (h *Handler) PreloadImage(src string, sizes ...string) template.HTML {
+	if h.parent != nil {
+		return h.parent.PreloadImage(src, sizes...)
+	}
+	href := h.AssetURL(src)
+
+	var variants []imageVariant
+	if !h.isDev {
+		variants = h.manifestIndex().imageVariants(src, h.base)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<link rel="preload" as="image" href="%s"`, template.HTMLEscapeString(href))
+	if len(variants) > 0 {
+		srcset := make([]string, len(variants))
+		for i, v := range variants {
+			srcset[i] = fmt.Sprintf("%s %dw", v.url, v.width)
+		}
+		s := "100vw"
+		if len(sizes) > 0 && sizes[0] != "" {
+			s = sizes[0]
+		}
+		fmt.Fprintf(&sb, ` imagesrcset="%s" imagesizes="%s"`,
+			template.HTMLEscapeString(strings.Join(srcset, ", ")),
+			template.HTMLEscapeString(s))
+	}
+	sb.WriteString(` fetchpriority="high">`)
+	return template.HTML(sb.String())
+}
@@ -2,9 +2,13 @@ package vite
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/url"
+	"path"
+	"sort"
 	"strings"
 )
 
@@ -27,38 +31,222 @@ type Chunk struct {
 	IsEntry        bool     `json:"isEntry"`
 	Imports        []string `json:"imports"`
 	DynamicImports []string `json:"dynamicImports"`
+	Assets         []string `json:"assets"`
+}
+
+// legacySuffix is the suffix @vitejs/plugin-legacy adds to the chunks it
+// builds for browsers without native ESM support, e.g. "src/main-legacy.ts"
+// for "src/main.ts".
+const legacySuffix = "-legacy"
+
+// legacyPolyfills is the source of the polyfill chunks of
+// @vitejs/plugin-legacy: "vite/legacy-polyfills" for modern browsers and
+// "vite/legacy-polyfills-legacy" for legacy ones.
+const legacyPolyfills = "vite/legacy-polyfills"
+
+// IsLegacy returns true if the chunk is built by @vitejs/plugin-legacy for
+// browsers without native ESM support, e.g. "src/main-legacy.ts" or the
+// legacy polyfills.
+func (c *Chunk) IsLegacy() bool {
+	src := c.Src
+	if src == "" {
+		src = c.Name
+	}
+	return strings.HasSuffix(strings.TrimSuffix(src, path.Ext(src)), legacySuffix)
+}
+
+// IsPolyfills returns true if the chunk contains the polyfills of
+// @vitejs/plugin-legacy, for modern or legacy browsers.
+func (c *Chunk) IsPolyfills() bool {
+	return strings.HasPrefix(c.Src, legacyPolyfills)
+}
+
+// IsCSS returns true if the chunk is a stylesheet, e.g. of a CSS-only
+// entry point like "src/styles.css", which Vite builds into a CSS file of
+// its own instead of a module.
+func (c *Chunk) IsCSS() bool {
+	return strings.HasSuffix(c.File, ".css")
+}
+
+// isPageEntry returns true if the chunk is an entry point of a page, i.e.
+// no legacy or polyfill chunk of @vitejs/plugin-legacy.
+func (c *Chunk) isPageEntry() bool {
+	return c != nil && c.IsEntry && !c.IsLegacy() && !c.IsPolyfills()
 }
 
 // ParseManifest parses the manifest file.
 func ParseManifest(r io.Reader) (*Manifest, error) {
 	var m Manifest
 	if err := json.NewDecoder(r).Decode(&m); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrManifestInvalid, err)
 	}
 	return &m, nil
 }
 
-// GetEntryPoint returns the entry point from the Vite manifest.
-func (m Manifest) GetEntryPoint() *Chunk {
-	for _, chunk := range m {
-		if chunk.IsEntry {
-			return chunk
-		}
+// readManifestFile reads and parses the manifest at name in fsys.
+func readManifestFile(fsys fs.FS, name string) (*Manifest, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, openManifestError(err)
 	}
-	return nil
+	defer f.Close()
+
+	m, err := ParseManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("vite: parse manifest: %w", err)
+	}
+	return m, nil
 }
 
-// GetEntryPoints returns the entry points from the manifest.
+// openManifestError wraps an error opening the manifest, adding
+// ErrManifestNotFound if the manifest does not exist.
+func openManifestError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("vite: open manifest: %w: %w", ErrManifestNotFound, err)
+	}
+	return fmt.Errorf("vite: open manifest: %w", err)
+}
+
+// keys returns the keys of the manifest in sorted order.
+func (m Manifest) keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetEntryPoint returns the entry point from the Vite manifest. If there are
+// multiple entry points, it returns the first one ordered by manifest key,
+// preferring scripts over CSS-only entry points. Like
+// [Manifest.GetEntryPoints], it skips the chunks of @vitejs/plugin-legacy.
+func (m Manifest) GetEntryPoint() *Chunk {
+	_, chunk := m.lookupEntryPoint("")
+	return chunk
+}
+
+// GetEntryPoints returns the entry points from the manifest, ordered by
+// manifest key. The legacy and polyfill chunks of @vitejs/plugin-legacy
+// are no entry points of their own, use [Manifest.GetLegacyEntryPoint] and
+// [Manifest.GetLegacyPolyfills] to pair them with the entry points.
 func (m Manifest) GetEntryPoints() []*Chunk {
 	var entryPoints []*Chunk
-	for _, chunk := range m {
-		if chunk.IsEntry {
+	for _, key := range m.keys() {
+		if chunk := m[key]; chunk.isPageEntry() {
 			entryPoints = append(entryPoints, chunk)
 		}
 	}
 	return entryPoints
 }
 
+// GetEntryPointByName returns the entry point with the given name from the
+// manifest, e.g. "main" for the entry point built from "src/main.tsx". If
+// multiple entry points share the name, it returns the first one ordered by
+// manifest key.
+func (m Manifest) GetEntryPointByName(name string) *Chunk {
+	for _, key := range m.keys() {
+		if chunk := m[key]; chunk.isPageEntry() && chunk.Name == name {
+			return chunk
+		}
+	}
+	return nil
+}
+
+// GetLegacyEntryPoint returns the chunk that @vitejs/plugin-legacy built
+// for the entry point with the given manifest key, e.g. the chunk of
+// "src/main-legacy.ts" for "src/main.ts". It returns nil if there is none.
+func (m Manifest) GetLegacyEntryPoint(key string) *Chunk {
+	ext := path.Ext(key)
+	chunk := m[strings.TrimSuffix(key, ext)+legacySuffix+ext]
+	if chunk == nil || !chunk.IsEntry {
+		return nil
+	}
+	return chunk
+}
+
+// GetLegacyPolyfills returns the polyfill chunks of @vitejs/plugin-legacy:
+// modern for modern browsers, built with the modernPolyfills option, and
+// legacy for browsers without native ESM support. Both are nil if the app
+// does not use the plugin.
+func (m Manifest) GetLegacyPolyfills() (modern, legacy *Chunk) {
+	return m[legacyPolyfills], m[legacyPolyfills+legacySuffix]
+}
+
+// lookupEntryPoint resolves the entry point for entry and returns its key
+// in the manifest with the chunk. If entry is empty, it returns the first
+// entry point ordered by manifest key. Otherwise, it tries the manifest key,
+// the source file, and the name of the entry points, in that order. Only
+// the manifest key matches the chunks of @vitejs/plugin-legacy.
+//
+// It scans the manifest once; the handler uses a [manifestIndex] instead.
+func (m Manifest) lookupEntryPoint(entry string) (string, *Chunk) {
+	if entry == "" {
+		// Prefer a script over a CSS-only entry point.
+		var first, firstScript string
+		for key, chunk := range m {
+			if !chunk.isPageEntry() {
+				continue
+			}
+			if first == "" || key < first {
+				first = key
+			}
+			if !chunk.IsCSS() && (firstScript == "" || key < firstScript) {
+				firstScript = key
+			}
+		}
+		if firstScript != "" {
+			return firstScript, m[firstScript]
+		}
+		if first != "" {
+			return first, m[first]
+		}
+		return "", nil
+	}
+
+	entry = strings.TrimPrefix(entry, "/")
+	if chunk := m[entry]; chunk != nil && chunk.IsEntry {
+		return entry, chunk
+	}
+	var bySrc, byName string
+	for key, chunk := range m {
+		if !chunk.isPageEntry() {
+			continue
+		}
+		if chunk.Src == entry && (bySrc == "" || key < bySrc) {
+			bySrc = key
+		}
+		if chunk.Name == entry && (byName == "" || key < byName) {
+			byName = key
+		}
+	}
+	if bySrc != "" {
+		return bySrc, m[bySrc]
+	}
+	if byName != "" {
+		return byName, m[byName]
+	}
+	return "", nil
+}
+
+// lookupEntryPoints resolves the given entry points, as lookupEntryPoint
+// does, and returns their keys. It returns the first entry point if
+// entries is empty.
+func (m Manifest) lookupEntryPoints(entries []string) ([]string, error) {
+	if len(entries) == 0 {
+		entries = []string{""}
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		key, chunk := m.lookupEntryPoint(entry)
+		if chunk == nil {
+			return nil, fmt.Errorf("vite: %w: %q", ErrEntryNotFound, entry)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
 // GetChunk returns the chunk with the given name from the manifest.
 //
 // The name is the name of the source file.
@@ -67,6 +255,56 @@ func (m Manifest) GetChunk(name string) (*Chunk, bool) {
 	return chunk, ok
 }
 
+// AssetURL returns the URL of the output file for the given source file,
+// e.g. "/assets/logo-Dk3p.png" for "src/assets/logo.png". This works for
+// every file Vite has processed, including images, fonts, and other static
+// imports.
+//
+// The source file is looked up by manifest key and by the src of a chunk.
+// If src is a file name without a directory, e.g. "logo.png", it also
+// matches a chunk with that file name, provided it is unique.
+func (m Manifest) AssetURL(src string) (string, bool) {
+	return m.assetURL(src, "/")
+}
+
+// assetURL is like AssetURL, with URLs under the given base.
+func (m Manifest) assetURL(src, base string) (string, bool) {
+	src = strings.TrimPrefix(src, "/")
+	if chunk := m[src]; chunk != nil && chunk.File != "" {
+		return base + chunk.File, true
+	}
+
+	var (
+		bySrc     string // first key with the src
+		byBase    string // first key with the base name of src
+		ambiguous bool
+	)
+	matchBase := !strings.Contains(src, "/")
+	for key, chunk := range m {
+		if chunk == nil || chunk.File == "" {
+			continue
+		}
+		if chunk.Src == src && (bySrc == "" || key < bySrc) {
+			bySrc = key
+		}
+		if matchBase && path.Base(chunk.Src) == src {
+			if byBase != "" && m[byBase].File != chunk.File {
+				ambiguous = true
+			}
+			if byBase == "" || key < byBase {
+				byBase = key
+			}
+		}
+	}
+	switch {
+	case bySrc != "":
+		return base + m[bySrc].File, true
+	case byBase != "" && !ambiguous:
+		return base + m[byBase].File, true
+	}
+	return "", false
+}
+
 // PluginReactPreamble returns the script tag that should be injected into the
 // HTML to enable React Fast Refresh.
 func PluginReactPreamble(server string) string {
@@ -84,8 +322,33 @@ func PluginReactPreamble(server string) string {
 //
 // The name is the name of the source file, e.g. "src/main.tsx".
 func (m Manifest) GenerateCSS(name string) string {
-	var sb strings.Builder
+	return m.generateCSS("/", name)
+}
+
+// generateCSS is like GenerateCSS, with URLs under the given base, for
+// one or more chunks. Stylesheets shared by chunks are linked once.
+func (m Manifest) generateCSS(base string, names ...string) string {
+	urls, _ := m.cssURLs(base, names...)
+	return cssTags(urls)
+}
+
+// CSSURLs returns the URLs of the stylesheets of the given chunk and its
+// imports, e.g. "/assets/main-4f2e1a.css". Unlike GenerateCSS, it returns
+// an error if the chunk or one of its imports is not in the manifest, along
+// with the URLs it found.
+//
+// The name is the name of the source file, e.g. "src/main.tsx".
+func (m Manifest) CSSURLs(name string) ([]string, error) {
+	return m.cssURLs("/", name)
+}
+
+// cssURLs is like CSSURLs, with URLs under the given base, for one or more
+// chunks. Stylesheets shared by chunks are returned once.
+func (m Manifest) cssURLs(base string, names ...string) ([]string, error) {
+	var urls []string
+	var errs []error
 	seen := make(map[string]bool)
+	seenCSS := make(map[string]bool)
 
 	var addCSS func(string)
 	addCSS = func(name string) {
@@ -94,25 +357,47 @@ func (m Manifest) GenerateCSS(name string) string {
 		}
 		seen[name] = true
 
-		chunk, ok := m[name]
-		if !ok {
-			return
+		chunk := m[name]
+		if chunk.IsCSS() && !seenCSS[chunk.File] {
+			seenCSS[chunk.File] = true
+			urls = append(urls, base+chunk.File)
 		}
-
 		for _, css := range chunk.CSS {
-			sb.WriteString(`<link rel="stylesheet" href="`)
-			sb.WriteString("/")
-			sb.WriteString(css)
-			sb.WriteString(`">`)
+			if seenCSS[css] {
+				continue
+			}
+			seenCSS[css] = true
+			urls = append(urls, base+css)
 		}
 
 		for _, imp := range chunk.Imports {
+			if m[imp] == nil {
+				errs = append(errs, &ChunkError{Key: name, Err: fmt.Errorf("%w %q", ErrUnresolvedImport, imp)})
+				continue
+			}
 			addCSS(imp)
 		}
 	}
 
-	addCSS(name)
+	for _, name := range names {
+		if m[name] == nil {
+			errs = append(errs, fmt.Errorf("vite: %w: %q", ErrChunkNotFound, name))
+			continue
+		}
+		addCSS(name)
+	}
 
+	return urls, errors.Join(errs...)
+}
+
+// cssTags returns the stylesheet links for the given URLs.
+func cssTags(urls []string) string {
+	var sb strings.Builder
+	for _, u := range urls {
+		sb.WriteString(`<link rel="stylesheet" href="`)
+		sb.WriteString(u)
+		sb.WriteString(`">`)
+	}
 	return sb.String()
 }
 
@@ -120,19 +405,57 @@ func (m Manifest) GenerateCSS(name string) string {
 //
 // The name is the name of the source file, e.g. "src/main.tsx".
 func (m Manifest) GenerateModules(name string) string {
-	chunk, ok := m[name]
-	if !ok {
-		return ""
+	return m.generateModules("/", name)
+}
+
+// generateModules is like GenerateModules, with URLs under the given base,
+// for one or more chunks.
+func (m Manifest) generateModules(base string, names ...string) string {
+	urls, _ := m.moduleURLs(base, names...)
+	return moduleTags(urls, nil)
+}
+
+// ModuleURLs returns the URLs of the module scripts of the given chunk,
+// e.g. "/assets/main-4f2e1a.js". Unlike GenerateModules, it returns an
+// error if the chunk is not in the manifest or has no file. Stylesheets,
+// i.e. CSS-only entry points, have no module script, see [Chunk.IsCSS].
+//
+// The name is the name of the source file, e.g. "src/main.tsx".
+func (m Manifest) ModuleURLs(name string) ([]string, error) {
+	return m.moduleURLs("/", name)
+}
+
+// moduleURLs is like ModuleURLs, with URLs under the given base, for one
+// or more chunks.
+func (m Manifest) moduleURLs(base string, names ...string) ([]string, error) {
+	var urls []string
+	var errs []error
+	for _, name := range names {
+		chunk := m[name]
+		switch {
+		case chunk == nil:
+			errs = append(errs, fmt.Errorf("vite: %w: %q", ErrChunkNotFound, name))
+		case chunk.File == "":
+			errs = append(errs, &ChunkError{Key: name, Err: ErrMissingFile})
+		case chunk.IsCSS():
+		default:
+			urls = append(urls, base+chunk.File)
+		}
 	}
+	return urls, errors.Join(errs...)
+}
 
+// moduleTags returns the module scripts for the given URLs, with the given
+// attributes.
+func moduleTags(urls []string, attrs ScriptAttributes) string {
 	var sb strings.Builder
-	if chunk.File != "" {
+	for _, u := range urls {
 		sb.WriteString(`<script type="module" src="`)
-		sb.WriteString("/")
-		sb.WriteString(chunk.File)
-		sb.WriteString(`"></script>`)
+		sb.WriteString(u)
+		sb.WriteString(`"`)
+		sb.WriteString(string(attrs.html()))
+		sb.WriteString(`></script>`)
 	}
-
 	return sb.String()
 }
 
@@ -140,34 +463,111 @@ func (m Manifest) GenerateModules(name string) string {
 //
 // The name is the name of the source file, e.g. "src/main.tsx".
 func (m Manifest) GeneratePreloadModules(name string) string {
+	return m.GeneratePreloadModulesWithPolicy(name, PreloadAll)
+}
+
+// GeneratePreloadModulesWithPolicy generates the preload modules for the
+// given chunk, following the given policy.
+//
+// The name is the name of the source file, e.g. "src/main.tsx".
+func (m Manifest) GeneratePreloadModulesWithPolicy(name string, policy PreloadPolicy) string {
+	return m.GeneratePreloadModulesWithOptions(name, PreloadOptions{Policy: policy})
+}
+
+// PreloadOptions control the modulepreload tags generated for a chunk.
+type PreloadOptions struct {
+	// Policy specifies which imports to preload.
+	Policy PreloadPolicy
+
+	// MaxPreloads is the maximum number of modulepreload tags to generate.
+	// Chunks closer to the entry point are preloaded first. Zero means
+	// no limit.
+	MaxPreloads int
+
+	// FetchPriority annotates the tags with a fetchpriority hint: "high"
+	// for the chunk itself and its direct imports, "low" for the imports
+	// further down the graph.
+	FetchPriority bool
+}
+
+// GeneratePreloadModulesWithOptions generates the preload modules for the
+// given chunk. The tags are ordered by import depth, i.e. the chunk comes
+// first, then its direct imports, then their imports, and so on.
+//
+// The name is the name of the source file, e.g. "src/main.tsx".
+func (m Manifest) GeneratePreloadModulesWithOptions(name string, opts PreloadOptions) string {
+	return m.generatePreloadModules("/", opts, name)
+}
+
+// generatePreloadModules is like GeneratePreloadModulesWithOptions, with
+// URLs under the given base, for one or more chunks. Chunks shared by them
+// are preloaded once.
+func (m Manifest) generatePreloadModules(base string, opts PreloadOptions, names ...string) string {
 	var sb strings.Builder
-	seen := make(map[string]bool)
+	m.walkPreloads(opts, names, func(file string, depth int) {
+		sb.WriteString(`<link rel="modulepreload" href="`)
+		sb.WriteString(base)
+		sb.WriteString(file)
+		if opts.FetchPriority {
+			if depth <= 1 {
+				sb.WriteString(`" fetchpriority="high`)
+			} else {
+				sb.WriteString(`" fetchpriority="low`)
+			}
+		}
+		sb.WriteString(`">`)
+	})
+	return sb.String()
+}
 
-	var addModulePreload func(string)
-	addModulePreload = func(name string) {
-		if seen[name] {
-			return
+// walkPreloads calls fn for the file of each chunk to preload for the
+// given chunks, breadth first, with the depth of the chunk in the import
+// graph.
+func (m Manifest) walkPreloads(opts PreloadOptions, names []string, fn func(file string, depth int)) {
+	if opts.Policy == PreloadNone {
+		return
+	}
+
+	type item struct {
+		name  string
+		depth int
+	}
+
+	seen := make(map[string]bool)
+	var queue []item
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			queue = append(queue, item{name: name})
 		}
-		seen[name] = true
+	}
+	count := 0
 
-		chunk, ok := m[name]
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+
+		chunk, ok := m[it.name]
 		if !ok {
-			return
+			continue
 		}
 
-		if chunk.File != "" {
-			sb.WriteString(`<link rel="modulepreload" href="`)
-			sb.WriteString("/")
-			sb.WriteString(chunk.File)
-			sb.WriteString(`">`)
+		if chunk.File != "" && !chunk.IsCSS() {
+			if opts.MaxPreloads > 0 && count >= opts.MaxPreloads {
+				break
+			}
+			count++
+			fn(chunk.File, it.depth)
 		}
 
+		if opts.Policy == PreloadDirect && it.depth > 0 {
+			continue
+		}
 		for _, imp := range chunk.Imports {
-			addModulePreload(imp)
+			if !seen[imp] {
+				seen[imp] = true
+				queue = append(queue, item{name: imp, depth: it.depth + 1})
+			}
 		}
 	}
-
-	addModulePreload(name)
-
-	return sb.String()
 }
@@ -0,0 +1,306 @@
+package vite_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/olivere/vite"
+)
+
+func parseTestManifest(t *testing.T, data string) *vite.Manifest {
+	t.Helper()
+	m, err := vite.ParseManifest(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+const nestedImportsManifest = `{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true, "imports": ["_a.js"]},
+  "_a.js": {"file": "assets/a.js", "imports": ["_b.js"]},
+  "_b.js": {"file": "assets/b.js"}
+}`
+
+func TestGeneratePreloadModulesWithPolicy(t *testing.T) {
+	m := parseTestManifest(t, nestedImportsManifest)
+
+	tests := []struct {
+		policy vite.PreloadPolicy
+		want   string
+	}{
+		{
+			policy: vite.PreloadAll,
+			want:   `<link rel="modulepreload" href="/assets/main.js"><link rel="modulepreload" href="/assets/a.js"><link rel="modulepreload" href="/assets/b.js">`,
+		},
+		{
+			policy: vite.PreloadDirect,
+			want:   `<link rel="modulepreload" href="/assets/main.js"><link rel="modulepreload" href="/assets/a.js">`,
+		},
+		{
+			policy: vite.PreloadNone,
+			want:   ``,
+		},
+	}
+	for _, tt := range tests {
+		if have := m.GeneratePreloadModulesWithPolicy("src/main.tsx", tt.policy); tt.want != have {
+			t.Errorf("policy %d: expected %q, got %q", tt.policy, tt.want, have)
+		}
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	m := parseTestManifest(t, `{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true, "imports": ["_a.js", "_gone.js"], "css": ["assets/main.css"]},
+  "_a.js": {"src": "_a.js"}
+}`)
+	fsys := fstest.MapFS{
+		"assets/main.js": &fstest.MapFile{},
+	}
+
+	err := m.Validate(fsys)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !errors.Is(err, vite.ErrMissingFile) {
+		t.Errorf("expected error to match ErrMissingFile, got %v", err)
+	}
+	if !errors.Is(err, vite.ErrUnresolvedImport) {
+		t.Errorf("expected error to match ErrUnresolvedImport, got %v", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected error to match fs.ErrNotExist, got %v", err)
+	}
+	var chunkErr *vite.ChunkError
+	if !errors.As(err, &chunkErr) {
+		t.Fatalf("expected a ChunkError, got %T", err)
+	}
+	if want := "_a.js"; chunkErr.Key != want {
+		t.Errorf("expected first chunk error for %q, got %q", want, chunkErr.Key)
+	}
+
+	if err := parseTestManifest(t, nestedImportsManifest).Validate(nil); err != nil {
+		t.Errorf("expected a valid manifest, got %v", err)
+	}
+}
+
+func TestGeneratePreloadModulesWithOptions(t *testing.T) {
+	m := parseTestManifest(t, `{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true, "imports": ["_a.js", "_c.js"]},
+  "_a.js": {"file": "assets/a.js", "imports": ["_b.js"]},
+  "_b.js": {"file": "assets/b.js"},
+  "_c.js": {"file": "assets/c.js"}
+}`)
+
+	// Ordered by depth: b is imported by a, so it comes after c.
+	have := m.GeneratePreloadModulesWithOptions("src/main.tsx", vite.PreloadOptions{FetchPriority: true})
+	want := `<link rel="modulepreload" href="/assets/main.js" fetchpriority="high">` +
+		`<link rel="modulepreload" href="/assets/a.js" fetchpriority="high">` +
+		`<link rel="modulepreload" href="/assets/c.js" fetchpriority="high">` +
+		`<link rel="modulepreload" href="/assets/b.js" fetchpriority="low">`
+	if want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+
+	have = m.GeneratePreloadModulesWithOptions("src/main.tsx", vite.PreloadOptions{MaxPreloads: 2})
+	want = `<link rel="modulepreload" href="/assets/main.js"><link rel="modulepreload" href="/assets/a.js">`
+	if want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+}
+
+func TestMergeManifests(t *testing.T) {
+	app := parseTestManifest(t, nestedImportsManifest)
+	admin := parseTestManifest(t, nestedImportsManifest)
+
+	m, err := vite.MergeManifests("", app, "/admin/", admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 6, len(*m); want != have {
+		t.Fatalf("expected %d chunks, got %d", want, have)
+	}
+
+	chunk, ok := m.GetChunk("admin/src/main.tsx")
+	if !ok {
+		t.Fatal("expected to find admin entry")
+	}
+	if want := "admin/assets/main.js"; chunk.File != want {
+		t.Errorf("expected file %q, got %q", want, chunk.File)
+	}
+	want := `<link rel="modulepreload" href="/admin/assets/main.js"><link rel="modulepreload" href="/admin/assets/a.js"><link rel="modulepreload" href="/admin/assets/b.js">`
+	if have := m.GeneratePreloadModules(chunk.Src); want != have {
+		t.Errorf("expected %q, got %q", want, have)
+	}
+
+	if _, err := vite.MergeManifests("app", app, "app", admin); err == nil {
+		t.Fatal("expected an error for colliding prefixes")
+	}
+}
+
+func TestManifestAssetURL(t *testing.T) {
+	m := parseTestManifest(t, `{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true, "assets": ["assets/logo-Dk3p.png"]},
+  "src/assets/logo.png": {"file": "assets/logo-Dk3p.png", "src": "src/assets/logo.png"},
+  "src/a/icon.svg": {"file": "assets/icon-1.svg", "src": "src/a/icon.svg"},
+  "src/b/icon.svg": {"file": "assets/icon-2.svg", "src": "src/b/icon.svg"}
+}`)
+
+	tests := []struct {
+		src  string
+		want string
+		ok   bool
+	}{
+		{"src/assets/logo.png", "/assets/logo-Dk3p.png", true},
+		{"/src/assets/logo.png", "/assets/logo-Dk3p.png", true},
+		{"logo.png", "/assets/logo-Dk3p.png", true},
+		{"icon.svg", "", false}, // ambiguous
+		{"missing.png", "", false},
+	}
+	for _, tt := range tests {
+		have, ok := m.AssetURL(tt.src)
+		if tt.want != have || tt.ok != ok {
+			t.Errorf("%s: expected (%q, %v), got (%q, %v)", tt.src, tt.want, tt.ok, have, ok)
+		}
+	}
+}
+
+func TestManifestGetEntryPointByName(t *testing.T) {
+	m := parseTestManifest(t, exampleManifest)
+	if chunk := m.GetEntryPointByName("foo"); chunk == nil || chunk.Src != "views/foo.js" {
+		t.Fatalf("expected entry point views/foo.js, got %+v", chunk)
+	}
+	if chunk := m.GetEntryPointByName("baz"); chunk != nil {
+		t.Fatalf("expected no entry point for a dynamic entry, got %+v", chunk)
+	}
+}
+
+func TestFragmentResolvesEntryByKeyAndName(t *testing.T) {
+	fsys := fstest.MapFS{
+		".vite/manifest.json": &fstest.MapFile{
+			Data: []byte(`{"main": {"file": "assets/main-1a2b.js", "name": "main", "isEntry": true, "css": ["assets/main-3c4d.css"]}}`),
+		},
+	}
+	for _, entry := range []string{"main", "/main"} {
+		f, err := vite.HTMLFragment(vite.Config{FS: fsys, ViteEntry: entry})
+		if err != nil {
+			t.Fatalf("%s: %v", entry, err)
+		}
+		for _, want := range []string{
+			`<link rel="stylesheet" href="/assets/main-3c4d.css">`,
+			`<script type="module" src="/assets/main-1a2b.js"></script>`,
+		} {
+			if !strings.Contains(string(f.Tags), want) {
+				t.Errorf("%s: expected fragment to contain %s, got:\n%s", entry, want, f.Tags)
+			}
+		}
+	}
+}
+
+func TestManifestEntryPointsAreSortedByKey(t *testing.T) {
+	m := parseTestManifest(t, exampleManifest)
+	for range 10 {
+		entries := m.GetEntryPoints()
+		if len(entries) != 2 || entries[0].Src != "views/bar.js" || entries[1].Src != "views/foo.js" {
+			t.Fatalf("expected entry points [views/bar.js views/foo.js], got %v", entries)
+		}
+		if chunk := m.GetEntryPoint(); chunk.Src != "views/bar.js" {
+			t.Fatalf("expected entry point views/bar.js, got %s", chunk.Src)
+		}
+	}
+}
+
+func TestManifestPrecacheEntries(t *testing.T) {
+	m := parseTestManifest(t, `{
+  "index.html": {"file": "assets/index-BJhT2b8v.js", "src": "index.html", "isEntry": true, "css": ["assets/index-DiwrgTda.css"], "assets": ["assets/logo-CT8v0Fq6.svg"]},
+  "src/sw.ts": {"file": "sw.js", "src": "src/sw.ts", "isEntry": true}
+}`)
+
+	entries := m.PrecacheEntries("/static/")
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.URL, "/static/") {
+			t.Errorf("expected URL %q to start with /static/", entry.URL)
+		}
+		hashed := entry.URL != "/static/sw.js"
+		if hashed && entry.Revision != "" {
+			t.Errorf("expected no revision for %s, got %q", entry.URL, entry.Revision)
+		}
+		if !hashed && entry.Revision == "" {
+			t.Errorf("expected a revision for %s", entry.URL)
+		}
+	}
+}
+
+func TestManifestCSSAndModuleURLs(t *testing.T) {
+	m := parseTestManifest(t, `{
+  "src/main.tsx": {"file": "assets/main.js", "src": "src/main.tsx", "isEntry": true, "css": ["assets/main.css"], "imports": ["_a.js", "_missing.js"]},
+  "_a.js": {"file": "assets/a.js", "css": ["assets/a.css"]},
+  "src/broken.tsx": {"src": "src/broken.tsx", "isEntry": true}
+}`)
+
+	css, err := m.CSSURLs("src/main.tsx")
+	if want, have := "/assets/main.css /assets/a.css", strings.Join(css, " "); want != have {
+		t.Errorf("expected CSS %q, got %q", want, have)
+	}
+	var chunkErr *vite.ChunkError
+	if !errors.As(err, &chunkErr) || chunkErr.Key != "src/main.tsx" || !errors.Is(err, vite.ErrUnresolvedImport) {
+		t.Errorf("expected an unresolved import of src/main.tsx, got %v", err)
+	}
+
+	modules, err := m.ModuleURLs("src/main.tsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "/assets/main.js", strings.Join(modules, " "); want != have {
+		t.Errorf("expected modules %q, got %q", want, have)
+	}
+	if _, err := m.ModuleURLs("src/broken.tsx"); !errors.Is(err, vite.ErrMissingFile) {
+		t.Errorf("expected ErrMissingFile, got %v", err)
+	}
+
+	if _, err := m.CSSURLs("src/nope.tsx"); !errors.Is(err, vite.ErrChunkNotFound) {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+	if _, err := m.ModuleURLs("src/nope.tsx"); !errors.Is(err, vite.ErrChunkNotFound) {
+		t.Errorf("expected ErrChunkNotFound, got %v", err)
+	}
+}
+
+func TestManifestLegacyChunks(t *testing.T) {
+	m := parseTestManifest(t, `{
+  "src/main-legacy.ts": {"file": "assets/main-legacy-CbWkDe1t.js", "name": "main", "src": "src/main-legacy.ts", "isEntry": true},
+  "src/main.ts": {"file": "assets/main-B8vE1kaQ.js", "name": "main", "src": "src/main.ts", "isEntry": true},
+  "vite/legacy-polyfills": {"file": "assets/polyfills-Dd6xTmfK.js", "src": "vite/legacy-polyfills", "isEntry": true},
+  "vite/legacy-polyfills-legacy": {"file": "assets/polyfills-legacy-C3r8Ylg2.js", "src": "vite/legacy-polyfills-legacy", "isEntry": true}
+}`)
+
+	entries := m.GetEntryPoints()
+	if len(entries) != 1 || entries[0].Src != "src/main.ts" {
+		t.Fatalf("expected entry points [src/main.ts], got %v", entries)
+	}
+	if chunk := m.GetEntryPoint(); chunk == nil || chunk.Src != "src/main.ts" {
+		t.Errorf("expected entry point src/main.ts, got %+v", chunk)
+	}
+	if chunk := m.GetEntryPointByName("main"); chunk == nil || chunk.Src != "src/main.ts" {
+		t.Errorf("expected entry point src/main.ts by name, got %+v", chunk)
+	}
+	if chunk := m.GetLegacyEntryPoint("src/main.ts"); chunk == nil || !chunk.IsLegacy() || chunk.Src != "src/main-legacy.ts" {
+		t.Errorf("expected legacy entry point src/main-legacy.ts, got %+v", chunk)
+	}
+	if chunk := m.GetLegacyEntryPoint("src/other.ts"); chunk != nil {
+		t.Errorf("expected no legacy entry point, got %+v", chunk)
+	}
+	modern, legacy := m.GetLegacyPolyfills()
+	if modern == nil || !modern.IsPolyfills() || modern.IsLegacy() {
+		t.Errorf("expected modern polyfills, got %+v", modern)
+	}
+	if legacy == nil || !legacy.IsPolyfills() || !legacy.IsLegacy() {
+		t.Errorf("expected legacy polyfills, got %+v", legacy)
+	}
+}
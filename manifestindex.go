@@ -0,0 +1,186 @@
+package vite
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// manifestIndex indexes a manifest for the lookups of every request, so
+// that large manifests, e.g. of multi page apps or design systems with
+// thousands of chunks, are not walked and sorted per request. It is built
+// once per manifest and must not be modified afterwards.
+type manifestIndex struct {
+	manifest *Manifest
+	keys     []string              // all keys, sorted
+	entries  []string              // keys of the page entry points, sorted
+	bySrc    map[string]string     // src of a page entry point to its key
+	byName   map[string]string     // name of a page entry point to its key
+	files    map[string]string     // src of a chunk to its file
+	baseName map[string]string     // base name of src to the file, "" if ambiguous
+	classes  map[string]AssetClass // file to its class, see Manifest.AssetClasses
+	budgets  sync.Map              // joined chunk keys to their AssetBudget
+}
+
+// newManifestIndex indexes the manifest. Where several chunks match, the
+// first one ordered by manifest key wins.
+func newManifestIndex(m *Manifest) *manifestIndex {
+	idx := &manifestIndex{
+		manifest: m,
+		bySrc:    make(map[string]string),
+		byName:   make(map[string]string),
+		files:    make(map[string]string),
+		baseName: make(map[string]string),
+	}
+	if m == nil {
+		return idx
+	}
+	idx.keys = m.keys()
+	idx.classes = m.AssetClasses()
+	for _, key := range idx.keys {
+		chunk := (*m)[key]
+		if chunk.isPageEntry() {
+			idx.entries = append(idx.entries, key)
+			if _, ok := idx.bySrc[chunk.Src]; !ok {
+				idx.bySrc[chunk.Src] = key
+			}
+			if _, ok := idx.byName[chunk.Name]; !ok {
+				idx.byName[chunk.Name] = key
+			}
+		}
+		if chunk == nil || chunk.File == "" {
+			continue
+		}
+		if _, ok := idx.files[chunk.Src]; !ok {
+			idx.files[chunk.Src] = chunk.File
+		}
+		base := path.Base(chunk.Src)
+		if file, ok := idx.baseName[base]; !ok {
+			idx.baseName[base] = chunk.File
+		} else if file != chunk.File {
+			idx.baseName[base] = ""
+		}
+	}
+	return idx
+}
+
+// chunk returns the chunk with the given key, or nil.
+func (idx *manifestIndex) chunk(key string) *Chunk {
+	if idx.manifest == nil {
+		return nil
+	}
+	return (*idx.manifest)[key]
+}
+
+// lookupEntryPoint is like [Manifest.lookupEntryPoint], without scanning
+// the manifest.
+func (idx *manifestIndex) lookupEntryPoint(entry string) (string, *Chunk) {
+	if entry == "" {
+		if len(idx.entries) == 0 {
+			return "", nil
+		}
+		// Prefer a script over a CSS-only entry point.
+		for _, key := range idx.entries {
+			if chunk := idx.chunk(key); !chunk.IsCSS() {
+				return key, chunk
+			}
+		}
+		return idx.entries[0], idx.chunk(idx.entries[0])
+	}
+
+	entry = strings.TrimPrefix(entry, "/")
+	if chunk := idx.chunk(entry); chunk != nil && chunk.IsEntry {
+		return entry, chunk
+	}
+	if key, ok := idx.bySrc[entry]; ok {
+		return key, idx.chunk(key)
+	}
+	if key, ok := idx.byName[entry]; ok {
+		return key, idx.chunk(key)
+	}
+	return "", nil
+}
+
+// lookupEntryPoints is like [Manifest.lookupEntryPoints].
+func (idx *manifestIndex) lookupEntryPoints(entries []string) ([]string, error) {
+	if len(entries) == 0 {
+		entries = []string{""}
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		key, chunk := idx.lookupEntryPoint(entry)
+		if chunk == nil {
+			return nil, fmt.Errorf("vite: %w: %q", ErrEntryNotFound, entry)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// assetURL is like [Manifest.assetURL].
+func (idx *manifestIndex) assetURL(src, base string) (string, bool) {
+	src = strings.TrimPrefix(src, "/")
+	if chunk := idx.chunk(src); chunk != nil && chunk.File != "" {
+		return base + chunk.File, true
+	}
+	if file, ok := idx.files[src]; ok {
+		return base + file, true
+	}
+	if !strings.Contains(src, "/") {
+		if file := idx.baseName[src]; file != "" {
+			return base + file, true
+		}
+	}
+	return "", false
+}
+
+// imageVariants returns the variants of the image src in the manifest,
+// ordered by width. Variants are images imported with a width query, as
+// generated by vite-imagetools, e.g. "src/assets/hero.png?w=800". They
+// share the prefix src+"?", so they are found by binary search in the
+// sorted keys.
+func (idx *manifestIndex) imageVariants(src, base string) []imageVariant {
+	prefix := strings.TrimPrefix(src, "/") + "?"
+	var variants []imageVariant
+	for i := sort.SearchStrings(idx.keys, prefix); i < len(idx.keys); i++ {
+		key := idx.keys[i]
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			break
+		}
+		chunk := idx.chunk(key)
+		if chunk == nil || chunk.File == "" {
+			continue
+		}
+		q, err := url.ParseQuery(rest)
+		if err != nil {
+			continue
+		}
+		w, err := strconv.Atoi(q.Get("w"))
+		if err != nil || w <= 0 {
+			continue
+		}
+		variants = append(variants, imageVariant{url: base + chunk.File, width: w})
+	}
+	sort.SliceStable(variants, func(i, j int) bool {
+		return variants[i].width < variants[j].width
+	})
+	return variants
+}
+
+// manifestIndex returns the index of the current manifest, building it on
+// first use after the manifest was loaded.
+func (h *Handler) manifestIndex() *manifestIndex {
+	m := h.manifest.Load()
+	if idx := h.index.Load(); idx != nil && idx.manifest == m {
+		return idx
+	}
+	// Concurrent requests may build the index twice, which is fine.
+	idx := newManifestIndex(m)
+	h.index.Store(idx)
+	return idx
+}
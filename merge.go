@@ -0,0 +1,77 @@
+package vite
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MergeManifests merges the manifests of two Vite builds into one, so that
+// a single [Handler] can serve both apps. Chunk keys and all paths (source,
+// file, imports, CSS, and assets) of a are prefixed with prefixA, and those
+// of b with prefixB, e.g. "src/main.tsx" of the build mounted at "admin"
+// becomes "admin/src/main.tsx". Either prefix may be empty.
+//
+// Pass the result as Config.Manifest. The file system passed to the handler
+// must serve the output of each build under its prefix, and entries have to
+// be referenced with their prefixed source path, e.g.
+// ViteEntry: "admin/src/main.tsx".
+//
+// It returns an error if the prefixed chunk keys of a and b collide.
+func MergeManifests(prefixA string, a *Manifest, prefixB string, b *Manifest) (*Manifest, error) {
+	merged := make(Manifest)
+	for _, part := range []struct {
+		prefix string
+		m      *Manifest
+	}{
+		{prefixA, a},
+		{prefixB, b},
+	} {
+		if part.m == nil {
+			return nil, fmt.Errorf("vite: merge manifests: manifest for prefix %q is nil", part.prefix)
+		}
+		prefix := strings.Trim(part.prefix, "/")
+		for key, chunk := range *part.m {
+			pkey := prefixPath(prefix, key)
+			if _, ok := merged[pkey]; ok {
+				return nil, fmt.Errorf("vite: merge manifests: duplicate chunk %q", pkey)
+			}
+			if chunk == nil {
+				merged[pkey] = nil
+				continue
+			}
+			merged[pkey] = &Chunk{
+				File:           prefixPath(prefix, chunk.File),
+				Name:           chunk.Name,
+				Src:            prefixPath(prefix, chunk.Src),
+				CSS:            prefixPaths(prefix, chunk.CSS),
+				IsDynamicEntry: chunk.IsDynamicEntry,
+				IsEntry:        chunk.IsEntry,
+				Imports:        prefixPaths(prefix, chunk.Imports),
+				DynamicImports: prefixPaths(prefix, chunk.DynamicImports),
+				Assets:         prefixPaths(prefix, chunk.Assets),
+			}
+		}
+	}
+	return &merged, nil
+}
+
+// prefixPath prefixes p with prefix, unless either is empty.
+func prefixPath(prefix, p string) string {
+	if prefix == "" || p == "" {
+		return p
+	}
+	return path.Join(prefix, p)
+}
+
+// prefixPaths prefixes all paths with prefix.
+func prefixPaths(prefix string, paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		out[i] = prefixPath(prefix, p)
+	}
+	return out
+}
@@ -3,27 +3,40 @@ package vite
 import (
 	"context"
 	"fmt"
+	"html"
+	"sort"
 	"strings"
 	"time"
 )
 
-type metadataKeyType string
-
-var metadataKey = metadataKeyType("metadata")
-
 // MetadataFromContext returns the metadata from the context.
 // Use [MetadataToContext] to set the metadata in the context.
 func MetadataFromContext(ctx context.Context) *Metadata {
-	if md, ok := ctx.Value(metadataKey).(*Metadata); ok {
-		return md
-	}
-	return nil
+	return RenderOptionsFromContext(ctx).Metadata
 }
 
 // MetadataToContext sets the metadata in the context.
 // It is the inverse of [MetadataFromContext].
 func MetadataToContext(ctx context.Context, md Metadata) context.Context {
-	return context.WithValue(ctx, metadataKey, &md)
+	return updateRenderOptions(ctx, func(opts *RenderOptions) {
+		opts.Metadata = &md
+	})
+}
+
+// title returns the title of the page, as rendered by String.
+func (m Metadata) title() string {
+	if m.TitleFunc == nil {
+		return m.Title
+	}
+	titleData := m.TitleFunc()
+	if titleData.Absolute != "" {
+		return titleData.Absolute
+	} else if titleData.Template != "" {
+		return fmt.Sprintf(titleData.Template, m.Title)
+	} else if titleData.Default != "" {
+		return titleData.Default
+	}
+	return m.Title
 }
 
 type TitleData struct {
@@ -52,7 +65,12 @@ type OpenGraph struct {
 	Locale        string
 	Type          string
 	PublishedTime time.Time
+	ModifiedTime  time.Time
+	Section       string
+	Tags          []string
 	Authors       []string
+	Videos        []OpenGraphVideo
+	Audio         []OpenGraphAudio
 }
 
 type OpenGraphImage struct {
@@ -62,6 +80,20 @@ type OpenGraphImage struct {
 	Alt    string
 }
 
+type OpenGraphVideo struct {
+	URL       string
+	SecureURL string
+	Type      string // e.g. "video/mp4"
+	Width     int
+	Height    int
+}
+
+type OpenGraphAudio struct {
+	URL       string
+	SecureURL string
+	Type      string // e.g. "audio/mpeg"
+}
+
 type Twitter struct {
 	Card        string // e.g. "summary_large_image"
 	Title       string
@@ -71,6 +103,14 @@ type Twitter struct {
 	CreatorID   string
 	Images      []string
 	App         *TwitterApp
+	Player      *TwitterPlayer
+}
+
+type TwitterPlayer struct {
+	URL    string // HTTPS URL of the iframe player
+	Width  int
+	Height int
+	Stream string // URL of the raw media stream, e.g. an MP4
 }
 
 type TwitterApp struct {
@@ -90,6 +130,14 @@ type TwitterAppURL struct {
 	IPad   string
 }
 
+// PreloadLink is a resource to preload, see [Metadata.Preloads].
+type PreloadLink struct {
+	Href        string
+	As          string // e.g. "font", "image", "style"
+	Type        string // e.g. "font/woff2"
+	CrossOrigin string // e.g. "anonymous", required for fonts
+}
+
 type Robots struct {
 	Index     bool
 	Follow    bool
@@ -145,6 +193,50 @@ type ThemeColor struct {
 	Media string
 }
 
+// Alternates are alternate versions of a page besides its translations,
+// rendered as <link rel="alternate"> tags.
+type Alternates struct {
+	Feeds []Feed
+	Media map[string]string // "only screen and (max-width: 600px)": "/mobile"
+	Types map[string]string // "application/pdf": "/report.pdf"
+}
+
+// Feed is an RSS or Atom feed of a page.
+type Feed struct {
+	Title string
+	URL   string
+	Type  string // "application/rss+xml" (default) or "application/atom+xml"
+}
+
+// AppLinks link a page to the apps that can open it, rendered as al:*
+// meta properties, see https://developers.facebook.com/docs/applinks.
+type AppLinks struct {
+	IOS     []AppLinkApple
+	IPhone  []AppLinkApple
+	IPad    []AppLinkApple
+	Android []AppLinkAndroid
+	Web     *AppLinkWeb
+}
+
+type AppLinkApple struct {
+	URL        string // e.g. "example://page/42"
+	AppStoreID string
+	AppName    string
+}
+
+type AppLinkAndroid struct {
+	Package string // e.g. "com.example.android"
+	URL     string
+	Class   string
+	AppName string
+}
+
+// AppLinkWeb is the web fallback of app links.
+type AppLinkWeb struct {
+	URL            string
+	ShouldFallback *bool
+}
+
 type Metadata struct {
 	Title       string
 	TitleFunc   func() TitleData
@@ -159,22 +251,30 @@ type Metadata struct {
 	Publisher       string
 	FormatDetection *FormatDetection
 
+	Base      string // e.g. "/app/", rendered first so that all URLs resolve against it
 	Canonical string
 	Languages map[string]string // "en-US": "/en-US"
 
+	Alternates *Alternates
+
 	OpenGraph *OpenGraph
 	Twitter   *Twitter
+	AppLinks  *AppLinks
 	Robots    *Robots
 	Icons     *Icons
 
 	Viewport *Viewport
 
+	HTTPEquiv map[string]string // "content-language": "de-DE"
+
 	Manifest string
 
+	Preconnect  []string      // origins, e.g. "https://fonts.gstatic.com"
+	DNSPrefetch []string      // origins
+	Preloads    []PreloadLink // e.g. fonts, rendered as link rel="preload"
+
 	// Verification map[string]string
 	// AppleWebApp
-	// Alternates
-	// AppLinks
 	// Archives
 	// Assets
 	// Bookmarks
@@ -183,33 +283,46 @@ type Metadata struct {
 	Other map[string]string
 }
 
-// String output for the metadata.
+// baseTag returns the base element, as rendered by String, or an empty
+// string if there is no base URL.
+func (m Metadata) baseTag() string {
+	if m.Base == "" {
+		return ""
+	}
+	return `<base href="` + html.EscapeString(m.Base) + "\" />\n"
+}
+
+// titleTag returns the title element, as rendered by String.
+func (m Metadata) titleTag() string {
+	return "<title>" + html.EscapeString(m.title()) + "</title>\n"
+}
+
+// String output for the metadata. All values are HTML-escaped, so that
+// metadata from a CMS or user input cannot break out of the title or an
+// attribute.
 func (m Metadata) String() string {
 	var sb strings.Builder
 
+	// Base, before any element with a URL
+	sb.WriteString(m.baseTag())
+
 	// Title
-	title := m.Title
-	if m.TitleFunc != nil {
-		titleData := m.TitleFunc()
-		if titleData.Absolute != "" {
-			title = titleData.Absolute
-		} else if titleData.Template != "" {
-			title = fmt.Sprintf(titleData.Template, m.Title)
-		} else if titleData.Default != "" {
-			title = titleData.Default
-		} else {
-			title = m.Title
-		}
+	sb.WriteString(m.titleTag())
+
+	// HTTPEquiv
+	for _, name := range sortedKeys(m.HTTPEquiv) {
+		sb.WriteString(`<meta http-equiv="`)
+		sb.WriteString(html.EscapeString(name))
+		sb.WriteString(`" content="`)
+		sb.WriteString(html.EscapeString(m.HTTPEquiv[name]))
+		sb.WriteString(`" />`)
+		sb.WriteString("\n")
 	}
-	sb.WriteString("<title>")
-	sb.WriteString(title)
-	sb.WriteString("</title>")
-	sb.WriteString("\n")
 
 	// Description
 	if m.Description != "" {
 		sb.WriteString(`<meta name="description" content="`)
-		sb.WriteString(m.Description)
+		sb.WriteString(html.EscapeString(m.Description))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -219,7 +332,7 @@ func (m Metadata) String() string {
 		// Width
 		if m.Viewport.Width != "" {
 			sb.WriteString(`<meta name="viewport" content="width=`)
-			sb.WriteString(m.Viewport.Width)
+			sb.WriteString(html.EscapeString(m.Viewport.Width))
 			if m.Viewport.InitialScale > 0 {
 				sb.WriteString(`,initial-scale=`)
 				sb.WriteString(fmt.Sprint(m.Viewport.InitialScale))
@@ -237,7 +350,7 @@ func (m Metadata) String() string {
 			}
 			if m.Viewport.ColorScheme != "" {
 				sb.WriteString(`,color-scheme=`)
-				sb.WriteString(m.Viewport.ColorScheme)
+				sb.WriteString(html.EscapeString(m.Viewport.ColorScheme))
 			}
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
@@ -245,10 +358,10 @@ func (m Metadata) String() string {
 		// ThemeColor
 		for _, themeColor := range m.Viewport.ThemeColor {
 			sb.WriteString(`<meta name="theme-color" content="`)
-			sb.WriteString(themeColor.Color)
+			sb.WriteString(html.EscapeString(themeColor.Color))
 			if themeColor.Media != "" {
 				sb.WriteString(`" media="`)
-				sb.WriteString(themeColor.Media)
+				sb.WriteString(html.EscapeString(themeColor.Media))
 			}
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
@@ -256,7 +369,7 @@ func (m Metadata) String() string {
 		// ColorScheme
 		if m.Viewport.ColorScheme != "" {
 			sb.WriteString(`<meta name="color-scheme" content="`)
-			sb.WriteString(m.Viewport.ColorScheme)
+			sb.WriteString(html.EscapeString(m.Viewport.ColorScheme))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
@@ -265,7 +378,7 @@ func (m Metadata) String() string {
 	// Generator
 	if m.Generator != "" {
 		sb.WriteString(`<meta name="generator" content="`)
-		sb.WriteString(m.Generator)
+		sb.WriteString(html.EscapeString(m.Generator))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -273,7 +386,7 @@ func (m Metadata) String() string {
 	// ApplicationName
 	if m.ApplicationName != "" {
 		sb.WriteString(`<meta name="application-name" content="`)
-		sb.WriteString(m.ApplicationName)
+		sb.WriteString(html.EscapeString(m.ApplicationName))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -281,7 +394,7 @@ func (m Metadata) String() string {
 	// Referrer
 	if m.Referrer != "" {
 		sb.WriteString(`<meta name="referrer" content="`)
-		sb.WriteString(m.Referrer)
+		sb.WriteString(html.EscapeString(m.Referrer))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -289,7 +402,7 @@ func (m Metadata) String() string {
 	// Keywords
 	if len(m.Keywords) > 0 {
 		sb.WriteString(`<meta name="keywords" content="`)
-		sb.WriteString(strings.Join(m.Keywords, ","))
+		sb.WriteString(html.EscapeString(strings.Join(m.Keywords, ",")))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -298,13 +411,13 @@ func (m Metadata) String() string {
 	for _, author := range m.Authors {
 		if author.Name != "" {
 			sb.WriteString(`<meta name="author" content="`)
-			sb.WriteString(author.Name)
+			sb.WriteString(html.EscapeString(author.Name))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if author.URL != "" {
 			sb.WriteString(`<link rel="author" href="`)
-			sb.WriteString(author.URL)
+			sb.WriteString(html.EscapeString(author.URL))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
@@ -313,7 +426,7 @@ func (m Metadata) String() string {
 	// Creator
 	if m.Creator != "" {
 		sb.WriteString(`<meta name="creator" content="`)
-		sb.WriteString(m.Creator)
+		sb.WriteString(html.EscapeString(m.Creator))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -321,7 +434,7 @@ func (m Metadata) String() string {
 	// Publisher
 	if m.Publisher != "" {
 		sb.WriteString(`<meta name="publisher" content="`)
-		sb.WriteString(m.Publisher)
+		sb.WriteString(html.EscapeString(m.Publisher))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
@@ -353,50 +466,87 @@ func (m Metadata) String() string {
 	// Canonical
 	if m.Canonical != "" {
 		sb.WriteString(`<link rel="canonical" href="`)
-		sb.WriteString(m.Canonical)
+		sb.WriteString(html.EscapeString(m.Canonical))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
 
 	// Languages
-	for lang, href := range m.Languages {
+	for _, lang := range sortedKeys(m.Languages) {
+		href := m.Languages[lang]
 		sb.WriteString(`<link rel="alternate" hreflang="`)
-		sb.WriteString(lang)
+		sb.WriteString(html.EscapeString(lang))
 		sb.WriteString(`" href="`)
-		sb.WriteString(href)
+		sb.WriteString(html.EscapeString(href))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
 
+	// Alternates
+	if m.Alternates != nil {
+		for _, feed := range m.Alternates.Feeds {
+			typ := feed.Type
+			if typ == "" {
+				typ = "application/rss+xml"
+			}
+			sb.WriteString(`<link rel="alternate" type="`)
+			sb.WriteString(html.EscapeString(typ))
+			if feed.Title != "" {
+				sb.WriteString(`" title="`)
+				sb.WriteString(html.EscapeString(feed.Title))
+			}
+			sb.WriteString(`" href="`)
+			sb.WriteString(html.EscapeString(feed.URL))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
+		for _, media := range sortedKeys(m.Alternates.Media) {
+			sb.WriteString(`<link rel="alternate" media="`)
+			sb.WriteString(html.EscapeString(media))
+			sb.WriteString(`" href="`)
+			sb.WriteString(html.EscapeString(m.Alternates.Media[media]))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
+		for _, typ := range sortedKeys(m.Alternates.Types) {
+			sb.WriteString(`<link rel="alternate" type="`)
+			sb.WriteString(html.EscapeString(typ))
+			sb.WriteString(`" href="`)
+			sb.WriteString(html.EscapeString(m.Alternates.Types[typ]))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
+	}
+
 	// OpenGraph
 	if m.OpenGraph != nil {
 		if m.OpenGraph.Title != "" {
 			sb.WriteString(`<meta property="og:title" content="`)
-			sb.WriteString(m.OpenGraph.Title)
+			sb.WriteString(html.EscapeString(m.OpenGraph.Title))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.OpenGraph.Description != "" {
 			sb.WriteString(`<meta property="og:description" content="`)
-			sb.WriteString(m.OpenGraph.Description)
+			sb.WriteString(html.EscapeString(m.OpenGraph.Description))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.OpenGraph.URL != "" {
 			sb.WriteString(`<meta property="og:url" content="`)
-			sb.WriteString(m.OpenGraph.URL)
+			sb.WriteString(html.EscapeString(m.OpenGraph.URL))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.OpenGraph.SiteName != "" {
 			sb.WriteString(`<meta property="og:site_name" content="`)
-			sb.WriteString(m.OpenGraph.SiteName)
+			sb.WriteString(html.EscapeString(m.OpenGraph.SiteName))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		for _, image := range m.OpenGraph.Images {
 			sb.WriteString(`<meta property="og:image" content="`)
-			sb.WriteString(image.URL)
+			sb.WriteString(html.EscapeString(image.URL))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 			if image.Width > 0 {
@@ -413,20 +563,68 @@ func (m Metadata) String() string {
 			}
 			if image.Alt != "" {
 				sb.WriteString(`<meta property="og:image:alt" content="`)
-				sb.WriteString(image.Alt)
+				sb.WriteString(html.EscapeString(image.Alt))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+		}
+		for _, video := range m.OpenGraph.Videos {
+			sb.WriteString(`<meta property="og:video" content="`)
+			sb.WriteString(html.EscapeString(video.URL))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+			if video.SecureURL != "" {
+				sb.WriteString(`<meta property="og:video:secure_url" content="`)
+				sb.WriteString(html.EscapeString(video.SecureURL))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+			if video.Type != "" {
+				sb.WriteString(`<meta property="og:video:type" content="`)
+				sb.WriteString(html.EscapeString(video.Type))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+			if video.Width > 0 {
+				sb.WriteString(`<meta property="og:video:width" content="`)
+				sb.WriteString(fmt.Sprint(video.Width))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+			if video.Height > 0 {
+				sb.WriteString(`<meta property="og:video:height" content="`)
+				sb.WriteString(fmt.Sprint(video.Height))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+		}
+		for _, audio := range m.OpenGraph.Audio {
+			sb.WriteString(`<meta property="og:audio" content="`)
+			sb.WriteString(html.EscapeString(audio.URL))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+			if audio.SecureURL != "" {
+				sb.WriteString(`<meta property="og:audio:secure_url" content="`)
+				sb.WriteString(html.EscapeString(audio.SecureURL))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+			if audio.Type != "" {
+				sb.WriteString(`<meta property="og:audio:type" content="`)
+				sb.WriteString(html.EscapeString(audio.Type))
 				sb.WriteString(`" />`)
 				sb.WriteString("\n")
 			}
 		}
 		if m.OpenGraph.Locale != "" {
 			sb.WriteString(`<meta property="og:locale" content="`)
-			sb.WriteString(m.OpenGraph.Locale)
+			sb.WriteString(html.EscapeString(m.OpenGraph.Locale))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.OpenGraph.Type != "" {
 			sb.WriteString(`<meta property="og:type" content="`)
-			sb.WriteString(m.OpenGraph.Type)
+			sb.WriteString(html.EscapeString(m.OpenGraph.Type))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
@@ -436,9 +634,27 @@ func (m Metadata) String() string {
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
+		if !m.OpenGraph.ModifiedTime.IsZero() {
+			sb.WriteString(`<meta property="article:modified_time" content="`)
+			sb.WriteString(m.OpenGraph.ModifiedTime.Format(time.RFC3339))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
+		if m.OpenGraph.Section != "" {
+			sb.WriteString(`<meta property="article:section" content="`)
+			sb.WriteString(html.EscapeString(m.OpenGraph.Section))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
+		for _, tag := range m.OpenGraph.Tags {
+			sb.WriteString(`<meta property="article:tag" content="`)
+			sb.WriteString(html.EscapeString(tag))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
 		for _, author := range m.OpenGraph.Authors {
 			sb.WriteString(`<meta property="article:author" content="`)
-			sb.WriteString(author)
+			sb.WriteString(html.EscapeString(author))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
@@ -448,69 +664,69 @@ func (m Metadata) String() string {
 	if m.Twitter != nil {
 		if m.Twitter.Card != "" {
 			sb.WriteString(`<meta name="twitter:card" content="`)
-			sb.WriteString(m.Twitter.Card)
+			sb.WriteString(html.EscapeString(m.Twitter.Card))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.Twitter.Title != "" {
 			sb.WriteString(`<meta name="twitter:title" content="`)
-			sb.WriteString(m.Twitter.Title)
+			sb.WriteString(html.EscapeString(m.Twitter.Title))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.Twitter.Description != "" {
 			sb.WriteString(`<meta name="twitter:description" content="`)
-			sb.WriteString(m.Twitter.Description)
+			sb.WriteString(html.EscapeString(m.Twitter.Description))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.Twitter.SiteID != "" {
 			sb.WriteString(`<meta name="twitter:site:id" content="`)
-			sb.WriteString(m.Twitter.SiteID)
+			sb.WriteString(html.EscapeString(m.Twitter.SiteID))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.Twitter.Creator != "" {
 			sb.WriteString(`<meta name="twitter:creator" content="`)
-			sb.WriteString(m.Twitter.Creator)
+			sb.WriteString(html.EscapeString(m.Twitter.Creator))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.Twitter.CreatorID != "" {
 			sb.WriteString(`<meta name="twitter:creator:id" content="`)
-			sb.WriteString(m.Twitter.CreatorID)
+			sb.WriteString(html.EscapeString(m.Twitter.CreatorID))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		for _, image := range m.Twitter.Images {
 			sb.WriteString(`<meta name="twitter:image" content="`)
-			sb.WriteString(image)
+			sb.WriteString(html.EscapeString(image))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		if m.Twitter.App != nil {
 			if m.Twitter.App.Name != "" {
 				sb.WriteString(`<meta name="twitter:app:name" content="`)
-				sb.WriteString(m.Twitter.App.Name)
+				sb.WriteString(html.EscapeString(m.Twitter.App.Name))
 				sb.WriteString(`" />`)
 				sb.WriteString("\n")
 			}
 			if m.Twitter.App.ID != nil {
 				if m.Twitter.App.ID.IPhone != "" {
 					sb.WriteString(`<meta name="twitter:app:id:iphone" content="`)
-					sb.WriteString(m.Twitter.App.ID.IPhone)
+					sb.WriteString(html.EscapeString(m.Twitter.App.ID.IPhone))
 					sb.WriteString(`" />`)
 					sb.WriteString("\n")
 				}
 				if m.Twitter.App.ID.IPad != "" {
 					sb.WriteString(`<meta name="twitter:app:id:ipad" content="`)
-					sb.WriteString(m.Twitter.App.ID.IPad)
+					sb.WriteString(html.EscapeString(m.Twitter.App.ID.IPad))
 					sb.WriteString(`" />`)
 					sb.WriteString("\n")
 				}
 				if m.Twitter.App.ID.GooglePlay != "" {
 					sb.WriteString(`<meta name="twitter:app:id:googleplay" content="`)
-					sb.WriteString(m.Twitter.App.ID.GooglePlay)
+					sb.WriteString(html.EscapeString(m.Twitter.App.ID.GooglePlay))
 					sb.WriteString(`" />`)
 					sb.WriteString("\n")
 				}
@@ -518,18 +734,83 @@ func (m Metadata) String() string {
 			if m.Twitter.App.URL != nil {
 				if m.Twitter.App.URL.IPhone != "" {
 					sb.WriteString(`<meta name="twitter:app:url:iphone" content="`)
-					sb.WriteString(m.Twitter.App.URL.IPhone)
+					sb.WriteString(html.EscapeString(m.Twitter.App.URL.IPhone))
 					sb.WriteString(`" />`)
 					sb.WriteString("\n")
 				}
 				if m.Twitter.App.URL.IPad != "" {
 					sb.WriteString(`<meta name="twitter:app:url:ipad" content="`)
-					sb.WriteString(m.Twitter.App.URL.IPad)
+					sb.WriteString(html.EscapeString(m.Twitter.App.URL.IPad))
 					sb.WriteString(`" />`)
 					sb.WriteString("\n")
 				}
 			}
 		}
+		if player := m.Twitter.Player; player != nil && player.URL != "" {
+			sb.WriteString(`<meta name="twitter:player" content="`)
+			sb.WriteString(html.EscapeString(player.URL))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+			if player.Width > 0 {
+				sb.WriteString(`<meta name="twitter:player:width" content="`)
+				sb.WriteString(fmt.Sprint(player.Width))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+			if player.Height > 0 {
+				sb.WriteString(`<meta name="twitter:player:height" content="`)
+				sb.WriteString(fmt.Sprint(player.Height))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+			if player.Stream != "" {
+				sb.WriteString(`<meta name="twitter:player:stream" content="`)
+				sb.WriteString(html.EscapeString(player.Stream))
+				sb.WriteString(`" />`)
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	// AppLinks
+	if m.AppLinks != nil {
+		property := func(name, content string) {
+			if content == "" {
+				return
+			}
+			sb.WriteString(`<meta property="al:`)
+			sb.WriteString(name)
+			sb.WriteString(`" content="`)
+			sb.WriteString(html.EscapeString(content))
+			sb.WriteString(`" />`)
+			sb.WriteString("\n")
+		}
+		for _, platform := range []struct {
+			name  string
+			links []AppLinkApple
+		}{
+			{"ios", m.AppLinks.IOS},
+			{"iphone", m.AppLinks.IPhone},
+			{"ipad", m.AppLinks.IPad},
+		} {
+			for _, link := range platform.links {
+				property(platform.name+":url", link.URL)
+				property(platform.name+":app_store_id", link.AppStoreID)
+				property(platform.name+":app_name", link.AppName)
+			}
+		}
+		for _, link := range m.AppLinks.Android {
+			property("android:package", link.Package)
+			property("android:url", link.URL)
+			property("android:class", link.Class)
+			property("android:app_name", link.AppName)
+		}
+		if m.AppLinks.Web != nil {
+			property("web:url", m.AppLinks.Web.URL)
+			if m.AppLinks.Web.ShouldFallback != nil {
+				property("web:should_fallback", fmt.Sprint(*m.AppLinks.Web.ShouldFallback))
+			}
+		}
 	}
 
 	// Robots
@@ -576,7 +857,7 @@ func (m Metadata) String() string {
 			}
 			if m.Robots.GoogleBot.MaxImagePreview != "" {
 				sb.WriteString(`,max-image-preview:`)
-				sb.WriteString(m.Robots.GoogleBot.MaxImagePreview)
+				sb.WriteString(html.EscapeString(m.Robots.GoogleBot.MaxImagePreview))
 			}
 			if m.Robots.GoogleBot.MaxSnippet >= 0 {
 				sb.WriteString(`,max-snippet:`)
@@ -591,43 +872,43 @@ func (m Metadata) String() string {
 	if m.Icons != nil {
 		for _, icon := range m.Icons.Icon {
 			sb.WriteString(`<link rel="icon" href="`)
-			sb.WriteString(icon.URL)
+			sb.WriteString(html.EscapeString(icon.URL))
 			if icon.Type != "" {
 				sb.WriteString(`" type="`)
-				sb.WriteString(icon.Type)
+				sb.WriteString(html.EscapeString(icon.Type))
 			}
 			if icon.Media != "" {
 				sb.WriteString(`" media="`)
-				sb.WriteString(icon.Media)
+				sb.WriteString(html.EscapeString(icon.Media))
 			}
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		for _, shortcut := range m.Icons.Shortcut {
 			sb.WriteString(`<link rel="shortcut icon" href="`)
-			sb.WriteString(shortcut)
+			sb.WriteString(html.EscapeString(shortcut))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		for _, apple := range m.Icons.Apple {
 			sb.WriteString(`<link rel="apple-touch-icon" href="`)
-			sb.WriteString(apple.URL)
+			sb.WriteString(html.EscapeString(apple.URL))
 			if len(apple.Sizes) > 0 {
 				sb.WriteString(`" sizes="`)
-				sb.WriteString(strings.Join(apple.Sizes, " "))
+				sb.WriteString(html.EscapeString(strings.Join(apple.Sizes, " ")))
 			}
 			if apple.Type != "" {
 				sb.WriteString(`" type="`)
-				sb.WriteString(apple.Type)
+				sb.WriteString(html.EscapeString(apple.Type))
 			}
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
 		for _, other := range m.Icons.Other {
 			sb.WriteString(`<link rel="`)
-			sb.WriteString(other.Rel)
+			sb.WriteString(html.EscapeString(other.Rel))
 			sb.WriteString(`" href="`)
-			sb.WriteString(other.URL)
+			sb.WriteString(html.EscapeString(other.URL))
 			sb.WriteString(`" />`)
 			sb.WriteString("\n")
 		}
@@ -636,20 +917,64 @@ func (m Metadata) String() string {
 	// Manifest
 	if m.Manifest != "" {
 		sb.WriteString(`<link rel="manifest" href="`)
-		sb.WriteString(m.Manifest)
+		sb.WriteString(html.EscapeString(m.Manifest))
+		sb.WriteString(`" />`)
+		sb.WriteString("\n")
+	}
+
+	// Resource hints
+	for _, origin := range m.Preconnect {
+		sb.WriteString(`<link rel="preconnect" href="`)
+		sb.WriteString(html.EscapeString(origin))
+		sb.WriteString(`" />`)
+		sb.WriteString("\n")
+	}
+	for _, origin := range m.DNSPrefetch {
+		sb.WriteString(`<link rel="dns-prefetch" href="`)
+		sb.WriteString(html.EscapeString(origin))
+		sb.WriteString(`" />`)
+		sb.WriteString("\n")
+	}
+	for _, preload := range m.Preloads {
+		sb.WriteString(`<link rel="preload" href="`)
+		sb.WriteString(html.EscapeString(preload.Href))
+		if preload.As != "" {
+			sb.WriteString(`" as="`)
+			sb.WriteString(html.EscapeString(preload.As))
+		}
+		if preload.Type != "" {
+			sb.WriteString(`" type="`)
+			sb.WriteString(html.EscapeString(preload.Type))
+		}
+		if preload.CrossOrigin != "" {
+			sb.WriteString(`" crossorigin="`)
+			sb.WriteString(html.EscapeString(preload.CrossOrigin))
+		}
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
 
 	// Other
-	for name, content := range m.Other {
+	for _, name := range sortedKeys(m.Other) {
+		content := m.Other[name]
 		sb.WriteString(`<meta name="`)
-		sb.WriteString(name)
+		sb.WriteString(html.EscapeString(name))
 		sb.WriteString(`" content="`)
-		sb.WriteString(content)
+		sb.WriteString(html.EscapeString(content))
 		sb.WriteString(`" />`)
 		sb.WriteString("\n")
 	}
 
 	return sb.String()
 }
+
+// sortedKeys returns the keys of m in sorted order, so that tags generated
+// from maps are rendered in a stable order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+package vite_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/vite"
+)
+
+func TestMetadataEscapesValues(t *testing.T) {
+	md := vite.Metadata{
+		Title:       `</title><script>alert(1)</script>`,
+		Description: `"><script>alert(2)</script>`,
+		Keywords:    []string{`a"b`, "c"},
+		Canonical:   "https://example.com/?a=1&b=2",
+		OpenGraph:   &vite.OpenGraph{Title: `it's "great"`},
+		Other:       map[string]string{`x" onload="alert(3)`: "<y>"},
+	}
+	s := md.String()
+	if strings.Contains(s, "<script>") || strings.Contains(s, `" onload="`) {
+		t.Fatalf("expected values to be escaped, got:\n%s", s)
+	}
+	for _, want := range []string{
+		`<title>&lt;/title&gt;&lt;script&gt;alert(1)&lt;/script&gt;</title>`,
+		`<meta name="keywords" content="a&#34;b,c" />`,
+		`<link rel="canonical" href="https://example.com/?a=1&amp;b=2" />`,
+		`<meta property="og:title" content="it&#39;s &#34;great&#34;" />`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected metadata to contain %s, got:\n%s", want, s)
+		}
+	}
+	if err := vite.ValidateHTML(strings.NewReader(s)); err != nil {
+		t.Errorf("expected valid HTML, got %v", err)
+	}
+}
+
+func TestMetadataAlternates(t *testing.T) {
+	md := vite.Metadata{
+		Languages: map[string]string{"de-DE": "/de"},
+		Alternates: &vite.Alternates{
+			Feeds: []vite.Feed{
+				{Title: "Blog", URL: "/feed.xml"},
+				{Title: "Blog (Atom)", URL: "/atom.xml", Type: "application/atom+xml"},
+			},
+			Media: map[string]string{"only screen and (max-width: 600px)": "https://m.example.com"},
+			Types: map[string]string{"application/pdf": "/report.pdf"},
+		},
+	}
+	s := md.String()
+	for _, want := range []string{
+		`<link rel="alternate" hreflang="de-DE" href="/de" />`,
+		`<link rel="alternate" type="application/rss+xml" title="Blog" href="/feed.xml" />`,
+		`<link rel="alternate" type="application/atom+xml" title="Blog (Atom)" href="/atom.xml" />`,
+		`<link rel="alternate" media="only screen and (max-width: 600px)" href="https://m.example.com" />`,
+		`<link rel="alternate" type="application/pdf" href="/report.pdf" />`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected metadata to contain %s, got:\n%s", want, s)
+		}
+	}
+}
+
+func TestMetadataAppLinks(t *testing.T) {
+	fallback := false
+	md := vite.Metadata{
+		AppLinks: &vite.AppLinks{
+			IOS:     []vite.AppLinkApple{{URL: "example://page/42", AppStoreID: "123456789", AppName: "Example"}},
+			Android: []vite.AppLinkAndroid{{Package: "com.example.android", URL: "example://page/42", AppName: "Example"}},
+			Web:     &vite.AppLinkWeb{URL: "https://example.com/page/42", ShouldFallback: &fallback},
+		},
+	}
+	want := `<meta property="al:ios:url" content="example://page/42" />
+<meta property="al:ios:app_store_id" content="123456789" />
+<meta property="al:ios:app_name" content="Example" />
+<meta property="al:android:package" content="com.example.android" />
+<meta property="al:android:url" content="example://page/42" />
+<meta property="al:android:app_name" content="Example" />
+<meta property="al:web:url" content="https://example.com/page/42" />
+<meta property="al:web:should_fallback" content="false" />
+`
+	if s := md.String(); !strings.Contains(s, want) {
+		t.Errorf("expected metadata to contain:\n%s\ngot:\n%s", want, s)
+	}
+}
+
+func TestMetadataOpenGraphMedia(t *testing.T) {
+	md := vite.Metadata{
+		OpenGraph: &vite.OpenGraph{
+			Type:          "article",
+			PublishedTime: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+			ModifiedTime:  time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC),
+			Section:       "Engineering",
+			Tags:          []string{"go", "vite"},
+			Videos: []vite.OpenGraphVideo{
+				{URL: "http://example.com/talk.mp4", SecureURL: "https://example.com/talk.mp4", Type: "video/mp4", Width: 1280, Height: 720},
+			},
+			Audio: []vite.OpenGraphAudio{
+				{URL: "https://example.com/talk.mp3", Type: "audio/mpeg"},
+			},
+		},
+	}
+	s := md.String()
+	for _, want := range []string{
+		`<meta property="og:video" content="http://example.com/talk.mp4" />
+<meta property="og:video:secure_url" content="https://example.com/talk.mp4" />
+<meta property="og:video:type" content="video/mp4" />
+<meta property="og:video:width" content="1280" />
+<meta property="og:video:height" content="720" />
+<meta property="og:audio" content="https://example.com/talk.mp3" />
+<meta property="og:audio:type" content="audio/mpeg" />
+`,
+		`<meta property="article:published_time" content="2024-05-01T08:00:00Z" />
+<meta property="article:modified_time" content="2024-05-02T09:30:00Z" />
+<meta property="article:section" content="Engineering" />
+<meta property="article:tag" content="go" />
+<meta property="article:tag" content="vite" />
+`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected metadata to contain:\n%s\ngot:\n%s", want, s)
+		}
+	}
+}
+
+func TestMetadataTwitterPlayer(t *testing.T) {
+	md := vite.Metadata{
+		Twitter: &vite.Twitter{
+			Card: "player",
+			Player: &vite.TwitterPlayer{
+				URL:    "https://example.com/embed/42?autoplay=1&muted=1",
+				Width:  480,
+				Height: 270,
+				Stream: "https://example.com/media/42.mp4",
+			},
+		},
+	}
+	want := `<meta name="twitter:card" content="player" />
+<meta name="twitter:player" content="https://example.com/embed/42?autoplay=1&amp;muted=1" />
+<meta name="twitter:player:width" content="480" />
+<meta name="twitter:player:height" content="270" />
+<meta name="twitter:player:stream" content="https://example.com/media/42.mp4" />
+`
+	if s := md.String(); !strings.Contains(s, want) {
+		t.Errorf("expected metadata to contain:\n%s\ngot:\n%s", want, s)
+	}
+}
+
+func TestMetadataHTTPEquiv(t *testing.T) {
+	md := vite.Metadata{
+		Title: "Home",
+		HTTPEquiv: map[string]string{
+			"X-UA-Compatible":  "IE=edge",
+			"content-language": "de-DE",
+			"refresh":          "30; url=/home?from=refresh&x=1",
+		},
+	}
+	want := `<title>Home</title>
+<meta http-equiv="X-UA-Compatible" content="IE=edge" />
+<meta http-equiv="content-language" content="de-DE" />
+<meta http-equiv="refresh" content="30; url=/home?from=refresh&amp;x=1" />
+`
+	if have := md.String(); have != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, have)
+	}
+}
+
+func TestMetadataResourceHints(t *testing.T) {
+	md := vite.Metadata{
+		Preconnect:  []string{"https://fonts.gstatic.com"},
+		DNSPrefetch: []string{"https://analytics.example.com"},
+		Preloads: []vite.PreloadLink{
+			{Href: "/fonts/inter.woff2", As: "font", Type: "font/woff2", CrossOrigin: "anonymous"},
+			{Href: "/hero.avif", As: "image"},
+		},
+	}
+	want := `<link rel="preconnect" href="https://fonts.gstatic.com" />
+<link rel="dns-prefetch" href="https://analytics.example.com" />
+<link rel="preload" href="/fonts/inter.woff2" as="font" type="font/woff2" crossorigin="anonymous" />
+<link rel="preload" href="/hero.avif" as="image" />
+`
+	if s := md.String(); !strings.Contains(s, want) {
+		t.Errorf("expected metadata to contain:\n%s\ngot:\n%s", want, s)
+	}
+}
+
+func TestMetadataBase(t *testing.T) {
+	md := vite.Metadata{
+		Base:      "/app/",
+		Title:     "Welcome",
+		Canonical: "/app/welcome",
+	}
+	s := md.String()
+	if want := `<base href="/app/" />` + "\n"; !strings.HasPrefix(s, want) {
+		t.Errorf("expected metadata to start with %s, got:\n%s", want, s)
+	}
+	if strings.Contains(vite.Metadata{Title: "Welcome"}.String(), "<base") {
+		t.Error("expected no base element without Base")
+	}
+}
@@ -0,0 +1,156 @@
+package vite
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// renderLatencyBuckets are the upper bounds of the render latency
+// histogram, in seconds.
+var renderLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// handlerMetrics holds the metrics of a handler and its groups and host
+// views. See [Handler.Metrics].
+type handlerMetrics struct {
+	m              *expvar.Map
+	pagesRendered  *expvar.Int
+	renderErrors   *expvar.Int
+	templateHits   *expvar.Int
+	templateMisses *expvar.Int
+	templateExact  *expvar.Int
+	templateFuzzy  *expvar.Int
+	assetRequests  *expvar.Int
+	notFound       *expvar.Int
+	renderLatency  *histogram
+}
+
+func newHandlerMetrics() *handlerMetrics {
+	hm := &handlerMetrics{
+		m:              new(expvar.Map).Init(),
+		pagesRendered:  new(expvar.Int),
+		renderErrors:   new(expvar.Int),
+		templateHits:   new(expvar.Int),
+		templateMisses: new(expvar.Int),
+		templateExact:  new(expvar.Int),
+		templateFuzzy:  new(expvar.Int),
+		assetRequests:  new(expvar.Int),
+		notFound:       new(expvar.Int),
+		renderLatency:  newHistogram(renderLatencyBuckets),
+	}
+	hm.m.Set("pages_rendered", hm.pagesRendered)
+	hm.m.Set("render_errors", hm.renderErrors)
+	hm.m.Set("template_hits", hm.templateHits)
+	hm.m.Set("template_misses", hm.templateMisses)
+	hm.m.Set("template_exact_matches", hm.templateExact)
+	hm.m.Set("template_variation_matches", hm.templateFuzzy)
+	hm.m.Set("asset_requests", hm.assetRequests)
+	hm.m.Set("not_found", hm.notFound)
+	hm.m.Set("render_latency_seconds", hm.renderLatency)
+	return hm
+}
+
+// Metrics returns the metrics of the handler as an expvar map, shared by
+// its groups and host views:
+//
+//   - pages_rendered is the number of pages rendered, including those
+//     that failed.
+//   - render_errors is the number of pages that failed to render.
+//   - template_hits is the number of pages rendered with a registered
+//     template, template_misses the number of pages that fell back to the
+//     default template. A steady rate of misses usually means that routes
+//     are not registered with the templates they should use.
+//   - template_exact_matches is the number of template hits by the exact
+//     name, template_variation_matches the number of hits by a variation
+//     of the name, e.g. "about.html" for "/about".
+//   - asset_requests is the number of files served.
+//   - not_found is the number of requests for files that do not exist.
+//   - render_latency_seconds is a histogram of the time to render a page,
+//     with the number of pages per upper bound, cumulative, as in
+//     Prometheus.
+//
+// Publish it to serve it with the other expvars at /debug/vars:
+//
+//	expvar.Publish("vite", h.Metrics())
+func (h *Handler) Metrics() *expvar.Map {
+	if h.parent != nil {
+		return h.parent.Metrics()
+	}
+	return h.metrics.m
+}
+
+// recordTemplateHit counts a page rendered with a registered template,
+// found by the exact name of the page, or by another spelling or a later
+// name of the template chain.
+func (hm *handlerMetrics) recordTemplateHit(exact bool) {
+	hm.templateHits.Add(1)
+	if exact {
+		hm.templateExact.Add(1)
+	} else {
+		hm.templateFuzzy.Add(1)
+	}
+}
+
+// histogram is an expvar.Var that counts observations in buckets.
+type histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []uint64 // per bound, not cumulative
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds:  bounds,
+		buckets: make([]uint64, len(bounds)),
+	}
+}
+
+// observeSince records the time elapsed since start.
+func (h *histogram) observeSince(start time.Time) {
+	h.observe(time.Since(start).Seconds())
+}
+
+// observe records the value v.
+func (h *histogram) observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += v
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.buckets[i]++
+			break
+		}
+	}
+}
+
+// String returns the histogram as JSON, e.g.
+// {"count":3,"sum":0.012,"buckets":{"0.001":1,"0.005":2,...,"+Inf":3}}.
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var sb []byte
+	sb = append(sb, `{"count":`...)
+	sb = strconv.AppendUint(sb, h.count, 10)
+	sb = append(sb, `,"sum":`...)
+	sb = strconv.AppendFloat(sb, h.sum, 'g', -1, 64)
+	sb = append(sb, `,"buckets":{`...)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.buckets[i]
+		le, _ := json.Marshal(strconv.FormatFloat(bound, 'g', -1, 64))
+		sb = append(sb, le...)
+		sb = append(sb, ':')
+		sb = strconv.AppendUint(sb, cumulative, 10)
+		sb = append(sb, ',')
+	}
+	sb = append(sb, `"+Inf":`...)
+	sb = strconv.AppendUint(sb, h.count, 10)
+	sb = append(sb, "}}"...)
+	return string(sb)
+}
@@ -0,0 +1,72 @@
+package vite
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MissingAssetsFunc is called when the number of requests for missing
+// assets within a window reaches the configured threshold. It is passed
+// the path of the last missing asset and the number of missing assets in
+// the current window. It is called at most once per window.
+type MissingAssetsFunc func(path string, count int)
+
+// missingAssets counts requests for missing assets, which usually means
+// that pages reference assets of another deploy than the one serving them.
+type missingAssets struct {
+	prefix    string
+	threshold int
+	window    time.Duration
+	notify    MissingAssetsFunc
+	now       func() time.Time
+
+	total atomic.Uint64
+
+	mu     sync.Mutex
+	start  time.Time
+	count  int
+	called bool
+}
+
+// matches reports whether path is an asset path.
+func (m *missingAssets) matches(path string) bool {
+	return strings.HasPrefix(path, m.prefix)
+}
+
+// record records a request for a missing asset.
+func (m *missingAssets) record(path string) {
+	m.total.Add(1)
+	if m.notify == nil || m.threshold <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	now := m.now()
+	if now.Sub(m.start) >= m.window {
+		m.start = now
+		m.count = 0
+		m.called = false
+	}
+	m.count++
+	count := m.count
+	call := count >= m.threshold && !m.called
+	if call {
+		m.called = true
+	}
+	m.mu.Unlock()
+
+	if call {
+		m.notify(path, count)
+	}
+}
+
+// MissingAssets returns the number of requests for paths under the assets
+// prefix that were not found since the handler was created.
+func (h *Handler) MissingAssets() uint64 {
+	if h.parent != nil {
+		return h.parent.MissingAssets()
+	}
+	return h.missing.total.Load()
+}
@@ -0,0 +1,59 @@
+package vite
+
+import (
+	"path"
+	"strings"
+)
+
+// Mount is the element an entry point mounts into, e.g. when a page
+// hydrates several islands or bundles rendered on the server.
+type Mount struct {
+	// Entry is the entry point, as configured.
+	Entry string `json:"entry"`
+	// ID is the id of the element, see [MountID].
+	ID string `json:"id"`
+}
+
+// MountID returns the id of the element the entry point mounts into. It is
+// derived from the entry name only, so the server and the client agree on
+// it without coordination: "src/widgets/cart.tsx" becomes
+// "vite-src-widgets-cart". Characters other than ASCII letters, digits,
+// "-", and "_" are replaced with "-". Entries that differ only in their
+// extension get the same id.
+func MountID(entry string) string {
+	entry = strings.TrimPrefix(entry, "/")
+	entry = strings.TrimSuffix(entry, path.Ext(entry))
+
+	var sb strings.Builder
+	sb.WriteString("vite")
+	dash := true
+	for _, r := range entry {
+		switch {
+		case r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9':
+			if dash {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	return sb.String()
+}
+
+// mounts returns the mount points of the entry points of the page, without
+// stylesheet entries, which mount nothing.
+func mounts(pd *PageData) []Mount {
+	entries := pd.ViteEntries
+	if len(entries) == 0 && pd.ViteEntry != "" {
+		entries = []string{pd.ViteEntry}
+	}
+	var list []Mount
+	for _, entry := range entries {
+		if !isStyleSheetEntry(entry) {
+			list = append(list, Mount{Entry: entry, ID: MountID(entry)})
+		}
+	}
+	return list
+}
@@ -0,0 +1,203 @@
+/*
+Package og serves dynamically generated Open Graph images, e.g. social cards
+for blog posts, and wires their URLs into [vite.Metadata].
+
+Images are drawn by a Go callback and served as PNG under a URL prefix,
+"/og/" by default, e.g. "/og/hello-world.png" for the slug "hello-world".
+
+Example:
+
+	cards, err := og.NewHandler(og.Config{
+		BaseURL: "https://example.com",
+		Draw: func(ctx context.Context, slug string) (image.Image, error) {
+			post, ok := posts[slug]
+			if !ok {
+				return nil, og.ErrNotFound
+			}
+			return drawCard(post), nil
+		},
+	})
+	if err != nil { ... }
+	mux.Handle("/og/", cards)
+
+	md := vite.Metadata{Title: post.Title}
+	cards.Apply(&md, post.Slug)
+	ctx = vite.MetadataToContext(ctx, md)
+*/
+package og
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/vite"
+)
+
+// ErrNotFound is returned by a [DrawFunc] if there is no image for a slug.
+// The handler responds with 404 Not Found.
+var ErrNotFound = errors.New("og: image not found")
+
+// DrawFunc draws the image for the given slug.
+type DrawFunc func(ctx context.Context, slug string) (image.Image, error)
+
+// Config is the configuration for the handler.
+type Config struct {
+	// Draw draws the image for a slug. It is required.
+	Draw DrawFunc
+
+	// Prefix is the URL path the images are served under. It defaults
+	// to "/og/".
+	Prefix string
+
+	// BaseURL is the absolute URL of the site, e.g. "https://example.com".
+	// Open Graph requires absolute image URLs, so it should be set in
+	// production. If it is empty, image URLs are relative.
+	BaseURL string
+
+	// Width and Height are the dimensions advertised in the metadata. They
+	// default to 1200x630, the size recommended by most social networks.
+	Width  int
+	Height int
+
+	// CacheControl is the Cache-Control header sent with each image. It
+	// defaults to "public, max-age=86400".
+	CacheControl string
+}
+
+// Handler serves Open Graph images.
+type Handler struct {
+	draw         DrawFunc
+	prefix       string
+	baseURL      string
+	width        int
+	height       int
+	cacheControl string
+}
+
+// NewHandler creates a new handler.
+func NewHandler(config Config) (*Handler, error) {
+	if config.Draw == nil {
+		return nil, errors.New("og: draw func is nil")
+	}
+	h := &Handler{
+		draw:         config.Draw,
+		prefix:       config.Prefix,
+		baseURL:      config.BaseURL,
+		width:        config.Width,
+		height:       config.Height,
+		cacheControl: config.CacheControl,
+	}
+	if h.prefix == "" {
+		h.prefix = "/og/"
+	}
+	if !strings.HasPrefix(h.prefix, "/") {
+		h.prefix = "/" + h.prefix
+	}
+	if !strings.HasSuffix(h.prefix, "/") {
+		h.prefix += "/"
+	}
+	if h.width <= 0 {
+		h.width = 1200
+	}
+	if h.height <= 0 {
+		h.height = 630
+	}
+	if h.cacheControl == "" {
+		h.cacheControl = "public, max-age=86400"
+	}
+	return h, nil
+}
+
+// URL returns the URL of the image for the given slug. The slug is path
+// escaped, so it may contain any characters, including slashes, e.g.
+// "/og/2024%2Fhello-world.png" for "2024/hello-world".
+func (h *Handler) URL(slug string) string {
+	p := h.prefix + url.PathEscape(slug) + ".png"
+	if h.baseURL == "" {
+		return p
+	}
+	u, err := url.JoinPath(h.baseURL, p)
+	if err != nil {
+		return p
+	}
+	return u
+}
+
+// Image returns the Open Graph image for the given slug.
+func (h *Handler) Image(slug string) vite.OpenGraphImage {
+	return vite.OpenGraphImage{
+		URL:    h.URL(slug),
+		Width:  h.width,
+		Height: h.height,
+	}
+}
+
+// Apply adds the image for the given slug to the Open Graph images of md,
+// and to the Twitter images if md has Twitter metadata.
+func (h *Handler) Apply(md *vite.Metadata, slug string) {
+	if md.OpenGraph == nil {
+		md.OpenGraph = &vite.OpenGraph{}
+	}
+	img := h.Image(slug)
+	md.OpenGraph.Images = append(md.OpenGraph.Images, img)
+	if md.Twitter != nil {
+		md.Twitter.Images = append(md.Twitter.Images, img.URL)
+	}
+}
+
+// ServeHTTP serves the image for the slug in the request path.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Match the escaped path, as URL escapes slashes in slugs.
+	name, ok := strings.CutPrefix(r.URL.EscapedPath(), (&url.URL{Path: h.prefix}).EscapedPath())
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	escaped, ok := strings.CutSuffix(name, ".png")
+	if !ok || escaped == "" || strings.Contains(escaped, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	slug, err := url.PathUnescape(escaped)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	img, err := h.draw(r.Context(), slug)
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Cache-Control", h.cacheControl)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,105 @@
+package og_test
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/vite"
+	"github.com/olivere/vite/og"
+)
+
+func TestHandler(t *testing.T) {
+	h, err := og.NewHandler(og.Config{
+		BaseURL: "https://example.com",
+		Draw: func(ctx context.Context, slug string) (image.Image, error) {
+			if slug != "hello-world" {
+				return nil, og.ErrNotFound
+			}
+			return image.NewRGBA(image.Rect(0, 0, 120, 63)), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/og/hello-world.png", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if want, have := "image/png", rec.Header().Get("Content-Type"); want != have {
+		t.Fatalf("expected Content-Type %q, got %q", want, have)
+	}
+	if _, err := png.Decode(rec.Body); err != nil {
+		t.Fatalf("expected a PNG image, got %v", err)
+	}
+
+	for _, path := range []string{"/og/unknown.png", "/og/hello-world.jpg", "/og/a/b.png"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected status %d, got %d", path, http.StatusNotFound, rec.Code)
+		}
+	}
+}
+
+func TestHandlerURLRoundTrip(t *testing.T) {
+	var drawn string
+	h, err := og.NewHandler(og.Config{
+		BaseURL: "https://example.com",
+		Draw: func(ctx context.Context, slug string) (image.Image, error) {
+			drawn = slug
+			return image.NewRGBA(image.Rect(0, 0, 120, 63)), nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, slug := range []string{"hello-world", "2024/hello world", "a?b#c", "50%"} {
+		u := h.URL(slug)
+		target, ok := strings.CutPrefix(u, "https://example.com")
+		if !ok {
+			t.Fatalf("%s: expected URL under the base URL, got %s", slug, u)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d for %s, got %d", slug, http.StatusOK, u, rec.Code)
+		}
+		if drawn != slug {
+			t.Fatalf("expected slug %q to be drawn, got %q", slug, drawn)
+		}
+	}
+}
+
+func TestHandlerApply(t *testing.T) {
+	h, err := og.NewHandler(og.Config{
+		BaseURL: "https://example.com",
+		Draw: func(ctx context.Context, slug string) (image.Image, error) {
+			return nil, og.ErrNotFound
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := vite.Metadata{Title: "Hello"}
+	h.Apply(&md, "hello-world")
+
+	out := md.String()
+	for _, want := range []string{
+		`<meta property="og:image" content="https://example.com/og/hello-world.png" />`,
+		`<meta property="og:image:width" content="1200" />`,
+		`<meta property="og:image:height" content="630" />`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected metadata to contain %s, got:\n%s", want, out)
+		}
+	}
+}
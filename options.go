@@ -0,0 +1,214 @@
+package vite
+
+import (
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+)
+
+// Option configures a handler created with [NewHandlerWithOptions].
+type Option func(*Config)
+
+// NewHandlerWithOptions creates a new handler serving files from fsys,
+// configured by the given options. It is equivalent to calling [NewHandler]
+// with a [Config] whose FS is fsys and that the options have been applied to.
+//
+// Example:
+//
+//	v, err := vite.NewHandlerWithOptions(os.DirFS("./frontend"),
+//		vite.WithDev(true),
+//		vite.WithViteURL("http://localhost:5173"),
+//		vite.WithEntry("src/main.ts"),
+//	)
+func NewHandlerWithOptions(fsys fs.FS, opts ...Option) (*Handler, error) {
+	config := Config{FS: fsys}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return NewHandler(config)
+}
+
+// WithConfig applies all fields of config, except FS. Use it as the first
+// option to start from an existing configuration.
+func WithConfig(config Config) Option {
+	return func(c *Config) {
+		fsys := c.FS
+		*c = config
+		c.FS = fsys
+	}
+}
+
+// WithDev sets whether the handler runs in development mode.
+func WithDev(isDev bool) Option {
+	return func(c *Config) {
+		c.IsDev = isDev
+	}
+}
+
+// WithViteURL sets the URL of the Vite dev server.
+func WithViteURL(url string) Option {
+	return func(c *Config) {
+		c.ViteURL = url
+	}
+}
+
+// WithEntry sets the entry point of the Vite app, e.g. "src/main.tsx".
+func WithEntry(entry string) Option {
+	return func(c *Config) {
+		c.ViteEntry = entry
+	}
+}
+
+// WithManifest sets the path of the Vite manifest, relative to the file
+// system of the handler.
+func WithManifest(path string) Option {
+	return func(c *Config) {
+		c.ViteManifest = path
+	}
+}
+
+// WithRoutes sets the path of the routes file in the file system, see
+// [Route].
+func WithRoutes(path string) Option {
+	return func(c *Config) {
+		c.ViteRoutes = path
+	}
+}
+
+// WithParsedManifest sets a pre-parsed Vite manifest.
+func WithParsedManifest(m *Manifest) Option {
+	return func(c *Config) {
+		c.Manifest = m
+	}
+}
+
+// WithPublicFS sets the file system to serve public files from in
+// development mode.
+func WithPublicFS(fsys fs.FS) Option {
+	return func(c *Config) {
+		c.PublicFS = fsys
+	}
+}
+
+// WithScaffolding sets the Vite template the app was scaffolded with.
+func WithScaffolding(s Scaffolding) Option {
+	return func(c *Config) {
+		c.ViteTemplate = s
+	}
+}
+
+// WithTemplateFS registers the files in fsys that match the patterns as
+// templates, see [Handler.RegisterTemplatesFS]. The patterns default to
+// "*.html".
+func WithTemplateFS(fsys fs.FS, patterns ...string) Option {
+	return func(c *Config) {
+		c.TemplateFS = fsys
+		c.TemplatePatterns = patterns
+	}
+}
+
+// WithReloadTemplates sets whether templates registered from files are
+// parsed again on each request in development mode.
+func WithReloadTemplates(reload bool) Option {
+	return func(c *Config) {
+		c.ReloadTemplates = reload
+	}
+}
+
+// WithSSR sets the renderer for server-side rendering.
+func WithSSR(r SSRRenderer) Option {
+	return func(c *Config) {
+		c.SSR = r
+	}
+}
+
+// WithTemplateEngine sets the engine used to render pages.
+func WithTemplateEngine(e TemplateEngine) Option {
+	return func(c *Config) {
+		c.TemplateEngine = e
+	}
+}
+
+// WithPreloadPolicy sets the default modulepreload policy.
+func WithPreloadPolicy(p PreloadPolicy) Option {
+	return func(c *Config) {
+		c.PreloadPolicy = p
+	}
+}
+
+// WithBotDetector sets the detector for crawlers.
+func WithBotDetector(d BotDetector) Option {
+	return func(c *Config) {
+		c.BotDetector = d
+	}
+}
+
+// WithNotFoundHandler sets the handler for requests of files that do not
+// exist.
+func WithNotFoundHandler(handler http.Handler) Option {
+	return func(c *Config) {
+		c.NotFoundHandler = handler
+	}
+}
+
+// WithErrorHandler sets the callback for errors while rendering a page.
+func WithErrorHandler(fn ErrorHandlerFunc) Option {
+	return func(c *Config) {
+		c.ErrorHandler = fn
+	}
+}
+
+// WithLogger sets the logger of the handler.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithCacheControl sets the callback for the Cache-Control header of the
+// files served by the handler, e.g. [DefaultCacheControl].
+func WithCacheControl(fn CacheControlFunc) Option {
+	return func(c *Config) {
+		c.CacheControl = fn
+	}
+}
+
+// WithTemplateChain sets the template chain of pages, e.g.
+// [DefaultTemplateChain].
+func WithTemplateChain(fn TemplateChainFunc) Option {
+	return func(c *Config) {
+		c.TemplateChain = fn
+	}
+}
+
+// WithDefaultEntry sets the entry point loaded from the dev server if no
+// other entry point is set, e.g. "src/main.ts".
+func WithDefaultEntry(entry string) Option {
+	return func(c *Config) {
+		c.DefaultEntry = entry
+	}
+}
+
+// WithModulePlacement sets where the module scripts of the entry points
+// are placed, e.g. [PlaceAtBodyEnd].
+func WithModulePlacement(p ModulePlacement) Option {
+	return func(c *Config) {
+		c.ModulePlacement = p
+	}
+}
+
+// WithNoScriptHTML sets the content shown to browsers without JavaScript.
+func WithNoScriptHTML(html template.HTML) Option {
+	return func(c *Config) {
+		c.NoScriptHTML = html
+	}
+}
+
+// WithServeIndexHTML sets whether the index.html of the Vite build is
+// served for pages if no templates are registered.
+func WithServeIndexHTML(serve bool) Option {
+	return func(c *Config) {
+		c.ServeIndexHTML = serve
+	}
+}
@@ -0,0 +1,171 @@
+package vite
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// Overlay is a file system that layers several file systems, see
+// [OverlayFS].
+type Overlay struct {
+	layers []fs.FS
+}
+
+// OverlayFS returns a file system that layers the given file systems, e.g.
+// the public directory over the Vite output directory, or generated files
+// over both:
+//
+//	fsys := vite.OverlayFS(generated, public, dist)
+//
+// Earlier layers take precedence: a file is opened from the first layer
+// that has it. Directories are merged, i.e. reading a directory lists the
+// files of all layers. Nil layers are skipped. Use [Overlay.Conflicts] to
+// find files that are shadowed by an earlier layer.
+func OverlayFS(layers ...fs.FS) *Overlay {
+	o := &Overlay{}
+	for _, layer := range layers {
+		if layer != nil {
+			o.layers = append(o.layers, layer)
+		}
+	}
+	return o
+}
+
+// Open opens the named file from the first layer that has it. Directories
+// list the files of all layers.
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o.layers {
+		f, err := layer.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		info, err := f.Stat()
+		if err != nil || !info.IsDir() {
+			return f, nil
+		}
+		entries, err := o.ReadDir(name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &overlayDir{File: f, entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// Stat returns the file info of the named file in the first layer that
+// has it.
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o.layers {
+		info, err := fs.Stat(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return info, err
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir reads the named directory of all layers that have it, and returns
+// the entries sorted by name. An entry of an earlier layer shadows the
+// entry of the same name of a later one.
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	var (
+		found   bool
+		seen    = make(map[string]bool)
+		entries []fs.DirEntry
+	)
+	for _, layer := range o.layers {
+		list, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, entry := range list {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Conflicts returns the files of each layer that a later layer has as
+// well, i.e. the files that shadow others, sorted by name. It walks all
+// layers but the last one, so put the largest layer last.
+func (o *Overlay) Conflicts() ([]string, error) {
+	seen := make(map[string]bool)
+	var conflicts []string
+	for i, layer := range o.layers {
+		if i == len(o.layers)-1 {
+			break
+		}
+		err := fs.WalkDir(layer, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || seen[name] {
+				return nil
+			}
+			for _, later := range o.layers[i+1:] {
+				if _, err := fs.Stat(later, name); err == nil {
+					seen[name] = true
+					conflicts = append(conflicts, name)
+					break
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts, nil
+}
+
+// overlayDir is a directory of an overlay, listing the entries of all
+// layers.
+type overlayDir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *overlayDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
@@ -0,0 +1,76 @@
+package vite_test
+
+import (
+	"io/fs"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/olivere/vite"
+)
+
+func TestOverlayFS(t *testing.T) {
+	generated := fstest.MapFS{
+		"assets/routes.json": &fstest.MapFile{Data: []byte("{}")},
+	}
+	public := fstest.MapFS{
+		"favicon.svg": &fstest.MapFile{Data: []byte("<svg>public</svg>")},
+	}
+	dist := fstest.MapFS{
+		"index.html":       &fstest.MapFile{Data: []byte("<html></html>")},
+		"favicon.svg":      &fstest.MapFile{Data: []byte("<svg>dist</svg>")},
+		"assets/main.js":   &fstest.MapFile{Data: []byte("main")},
+		"assets/routes.js": &fstest.MapFile{Data: []byte("routes")},
+	}
+	overlay := vite.OverlayFS(generated, nil, public, dist)
+
+	if err := fstest.TestFS(overlay, "index.html", "favicon.svg", "assets/main.js", "assets/routes.js", "assets/routes.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(overlay, "favicon.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "<svg>public</svg>", string(data); want != have {
+		t.Errorf("expected the file of the earlier layer %q, got %q", want, have)
+	}
+
+	entries, err := fs.ReadDir(overlay, "assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"main.js", "routes.js", "routes.json"}; !reflect.DeepEqual(want, names) {
+		t.Errorf("expected the merged directory %v, got %v", want, names)
+	}
+
+	conflicts, err := overlay.Conflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"favicon.svg"}; !reflect.DeepEqual(want, conflicts) {
+		t.Errorf("expected conflicts %v, got %v", want, conflicts)
+	}
+}
+
+func TestHandlerLogsShadowedFiles(t *testing.T) {
+	var buf strings.Builder
+	_, err := vite.NewHandler(vite.Config{
+		FS:       fstest.MapFS{"favicon.svg": &fstest.MapFile{Data: []byte("<svg/>")}},
+		PublicFS: fstest.MapFS{"favicon.svg": &fstest.MapFile{Data: []byte("<svg/>")}},
+		IsDev:    true,
+		Logger:   slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `msg="Public files shadow files of FS" files=[favicon.svg]`; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected log to contain %s, got:\n%s", want, buf.String())
+	}
+}
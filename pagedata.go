@@ -0,0 +1,73 @@
+package vite
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// PageDataJSON is the JSON response of the handler for pages requested
+// with "Accept: application/json", if Config.ServePageData is set. Client
+// side route transitions can fetch it to update the page without loading
+// the HTML.
+type PageDataJSON struct {
+	// Title is the title of the page.
+	Title string `json:"title"`
+	// Description is the description of the page.
+	Description string `json:"description,omitempty"`
+	// Head are the tags of the page metadata, as rendered into the head.
+	Head string `json:"head,omitempty"`
+	// Entries are the entry points of the page, if set.
+	Entries []string `json:"entries,omitempty"`
+	// Mounts are the elements the entry points mount into, see [MountID].
+	Mounts []Mount `json:"mounts,omitempty"`
+	// Data is the data of the page, see [RenderOptions].
+	Data any `json:"data,omitempty"`
+}
+
+// acceptsJSON returns true if the request prefers JSON over HTML, i.e. its
+// Accept header lists application/json before text/html.
+func acceptsJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || params["q"] == "0" {
+				continue
+			}
+			switch mediaType {
+			case "application/json":
+				return true
+			case "text/html":
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// servePageData writes the data of the page as JSON.
+func (h *Handler) servePageData(w http.ResponseWriter, r *http.Request, page PageData, md *Metadata) {
+	resp := PageDataJSON{
+		Entries: page.ViteEntries,
+		Mounts:  page.Mounts,
+		Data:    page.Data,
+	}
+	if len(resp.Entries) == 0 && page.ViteEntry != "" {
+		resp.Entries = []string{page.ViteEntry}
+	}
+	if md != nil {
+		resp.Title = md.title()
+		resp.Description = md.Description
+		resp.Head = string(page.Metadata)
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		h.serveError(w, r, fmt.Errorf("vite: encode page data: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
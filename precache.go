@@ -0,0 +1,111 @@
+package vite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// PrecacheEntry is an entry of a service worker precache manifest, in the
+// format of Workbox.
+type PrecacheEntry struct {
+	// URL is the URL of the file.
+	URL string `json:"url"`
+	// Revision is the revision of the file. It is empty for files whose
+	// name contains a content hash, as the URL changes with the content.
+	Revision string `json:"revision,omitempty"`
+}
+
+// hashedFileRegexp matches file names with a content hash as generated by
+// Vite, e.g. "assets/index-BJhT2b8v.js".
+var hashedFileRegexp = regexp.MustCompile(`[-.][A-Za-z0-9_-]{8,}\.[A-Za-z0-9]+$`)
+
+// isHashedFile returns true if the name of the file has a content hash as
+// generated by Vite. The URL of such a file changes with its content.
+func isHashedFile(file string) bool {
+	return hashedFileRegexp.MatchString(file)
+}
+
+// PrecacheEntries returns the precache entries for all files referenced by
+// the manifest, with URLs under the given prefix, e.g. "/". Files without a
+// content hash in their name get the fingerprint of the manifest as
+// revision, so that they are fetched again after each build.
+//
+// Pass the entries to precacheAndRoute of Workbox, or use them in a
+// hand-rolled service worker.
+func (m Manifest) PrecacheEntries(prefix string) []PrecacheEntry {
+	files := m.Files()
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+
+	var revision string
+	entries := make([]PrecacheEntry, 0, len(files))
+	for _, file := range files {
+		entry := PrecacheEntry{URL: prefix + file}
+		if !isHashedFile(file) {
+			if revision == "" {
+				revision = manifestFingerprint(files)
+			}
+			entry.Revision = revision
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// manifestFingerprint returns a short fingerprint of the files of a
+// manifest.
+func manifestFingerprint(files []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(files, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// PrecacheEntries returns the precache entries for the current manifest,
+// see [Manifest.PrecacheEntries]. It returns nil in development mode.
+// Templates can use the vitePrecache function to embed them as a
+// JavaScript array, e.g. {{ vitePrecache "/" }}.
+func (h *Handler) PrecacheEntries(prefix string) []PrecacheEntry {
+	if h.parent != nil {
+		return h.parent.PrecacheEntries(prefix)
+	}
+	if h.isDev {
+		return nil
+	}
+	return h.manifest.Load().PrecacheEntries(prefix)
+}
+
+// precacheJS returns the precache entries as a JavaScript array.
+func (h *Handler) precacheJS(prefix string) (template.JS, error) {
+	entries := h.PrecacheEntries(prefix)
+	if entries == nil {
+		entries = []PrecacheEntry{}
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(data), nil
+}
+
+// PrecacheHandler returns a handler that serves the precache entries for
+// the current manifest as JSON, e.g. for a service worker to fetch on
+// install. It serves an empty list in development mode. The response has
+// an ETag that changes with the build, so that clients get a 304 Not
+// Modified until a new version of the frontend is deployed.
+func (h *Handler) PrecacheHandler(prefix string) http.Handler {
+	if h.parent != nil {
+		return h.parent.PrecacheHandler(prefix)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		js, err := h.precacheJS(prefix)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		data := []byte(js)
+		serveJSON(w, r, data, jsonETag(data))
+	})
+}
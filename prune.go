@@ -0,0 +1,174 @@
+package vite
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ManifestVersion is a manifest of a deployed version of the Vite app.
+type ManifestVersion struct {
+	// Name identifies the version, e.g. the path of the manifest file.
+	Name string
+
+	// ModTime is the time the version was deployed.
+	ModTime time.Time
+
+	// Manifest is the parsed manifest.
+	Manifest *Manifest
+}
+
+// ReadManifestVersions reads all manifests in fsys that match the given
+// pattern (see [fs.Glob]), e.g. ".vite/manifest*.json". The versions are
+// sorted by modification time, newest first, so the N most recent
+// versions are ReadManifestVersions(...)[:N].
+func ReadManifestVersions(fsys fs.FS, pattern string) ([]ManifestVersion, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("vite: glob manifests: %w", err)
+	}
+
+	versions := make([]ManifestVersion, 0, len(names))
+	for _, name := range names {
+		v, err := readManifestVersion(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].ModTime.After(versions[j].ModTime)
+	})
+	return versions, nil
+}
+
+func readManifestVersion(fsys fs.FS, name string) (ManifestVersion, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ManifestVersion{}, openManifestError(err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return ManifestVersion{}, fmt.Errorf("vite: stat manifest: %w", err)
+	}
+	m, err := ParseManifest(f)
+	if err != nil {
+		return ManifestVersion{}, fmt.Errorf("vite: parse manifest %s: %w", name, err)
+	}
+	return ManifestVersion{Name: name, ModTime: fi.ModTime(), Manifest: m}, nil
+}
+
+// Files returns the output files referenced by the manifest, i.e. the
+// JavaScript, CSS, and static asset files of all chunks.
+func (m Manifest) Files() []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(file string) {
+		if file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, chunk := range m {
+		if chunk == nil {
+			continue
+		}
+		add(chunk.File)
+		for _, css := range chunk.CSS {
+			add(css)
+		}
+		for _, asset := range chunk.Assets {
+			add(asset)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// StaleAssets returns the assets in the Vite output directory dir that
+// only the outdated versions reference, relative to dir. The versions are
+// sorted newest first, as returned by [ReadManifestVersions], and the
+// first keep versions are kept. See [Prune] for which files are
+// considered.
+func StaleAssets(dir string, versions []ManifestVersion, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, errors.New("vite: no manifest versions to keep")
+	}
+	for _, v := range versions {
+		if v.Manifest == nil {
+			return nil, fmt.Errorf("vite: manifest of version %q is nil", v.Name)
+		}
+	}
+	if len(versions) <= keep {
+		return nil, nil
+	}
+
+	referenced := make(map[string]bool)
+	for _, v := range versions[:keep] {
+		for _, file := range v.Manifest.Files() {
+			referenced[path.Clean(file)] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var stale []string
+	for _, v := range versions[keep:] {
+		for _, file := range v.Manifest.Files() {
+			file = path.Clean(file)
+			if referenced[file] || seen[file] || !isHashedFile(file) || !fs.ValidPath(file) {
+				continue
+			}
+			seen[file] = true
+			fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file)))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("vite: stat asset: %w", err)
+			}
+			if fi.Mode().IsRegular() {
+				stale = append(stale, file)
+			}
+		}
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
+
+// Prune deletes the assets in the Vite output directory dir that only the
+// outdated versions reference. The versions are sorted newest first, as
+// returned by [ReadManifestVersions], and the first keep versions, i.e.
+// the current and keep-1 previous versions, are kept. It returns the
+// paths of the deleted files, relative to dir.
+//
+// Prune is meant for rolling deploys, where new assets are copied next to
+// the old ones and HTML served by the previous versions may still reference
+// old assets for a while.
+//
+// Only files that a manifest of an outdated version references, and none
+// of the kept versions does, are deleted, and only if their name has a
+// content hash as generated by Vite, e.g. "assets/index-BJhT2b8v.js".
+// Files unknown to the manifests, such as files copied from the public
+// directory, and the manifests themselves are never deleted.
+func Prune(dir string, versions []ManifestVersion, keep int) ([]string, error) {
+	stale, err := StaleAssets(dir, versions, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]string, 0, len(stale))
+	for _, name := range stale {
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return removed, fmt.Errorf("vite: prune: %w", err)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
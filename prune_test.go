@@ -0,0 +1,117 @@
+package vite_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/vite"
+)
+
+func TestPruneKeepsAssetsOfRetainedManifests(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".vite/manifest.json":      `{"main.js":{"file":"assets/main-Bq3xN8dK.js","css":["assets/main-Cz7Lw2pE.css"],"assets":["assets/logo-E9mKs1aQ.png"],"isEntry":true}}`,
+		".vite/manifest-old.json":  `{"main.js":{"file":"assets/main-D4hYt6vR.js","assets":["assets/logo-E9mKs1aQ.png"],"isEntry":true}}`,
+		"assets/main-Bq3xN8dK.js":  "",
+		"assets/main-Cz7Lw2pE.css": "",
+		"assets/main-D4hYt6vR.js":  "",
+		"assets/logo-E9mKs1aQ.png": "",
+		"assets/main-F2jUc5bW.js":  "",
+		"favicon.ico":              "",
+	}
+	writeFiles(t, dir, files)
+	deployedBefore(t, dir, ".vite/manifest-old.json")
+
+	versions, err := vite.ReadManifestVersions(os.DirFS(dir), ".vite/manifest*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected %d versions, got %d", 2, len(versions))
+	}
+
+	removed, err := vite.Prune(dir, versions, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("expected to remove nothing when keeping all versions, got %v", removed)
+	}
+
+	removed, err = vite.Prune(dir, versions, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"assets/main-D4hYt6vR.js"}; !slices.Equal(want, removed) {
+		t.Fatalf("expected to remove %v, got %v", want, removed)
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if exists := err == nil; exists == slices.Contains(removed, name) {
+			t.Fatalf("unexpected state of %s after prune: exists=%v", name, exists)
+		}
+	}
+
+	if _, err := vite.Prune(dir, versions, 0); err == nil || !strings.Contains(err.Error(), "no manifest versions") {
+		t.Fatalf("expected error when keeping no versions, got %v", err)
+	}
+}
+
+func TestPruneKeepsPublicFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".vite/manifest.json":         `{"main.js":{"file":"assets/main-Bq3xN8dK.js","isEntry":true}}`,
+		".vite/manifest-old.json":     `{"main.js":{"file":"assets/main-F2jUc5bW.js","assets":["assets/logo.png"],"isEntry":true}}`,
+		"assets/main-Bq3xN8dK.js":     "",
+		"assets/main-F2jUc5bW.js":     "",
+		"assets/logo.png":             "",
+		"assets/photo-IMG_1234.jpg":   "",
+		"assets/hero-gradient.png":    "",
+		"assets/main-G8pRv3nL.js.map": "",
+	}
+	writeFiles(t, dir, files)
+	deployedBefore(t, dir, ".vite/manifest-old.json")
+
+	versions, err := vite.ReadManifestVersions(os.DirFS(dir), ".vite/manifest*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err := vite.Prune(dir, versions, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"assets/main-F2jUc5bW.js"}; !slices.Equal(want, removed) {
+		t.Fatalf("expected to remove %v, got %v", want, removed)
+	}
+	for _, name := range []string{"assets/logo.png", "assets/photo-IMG_1234.jpg", "assets/hero-gradient.png", "assets/main-G8pRv3nL.js.map"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected %s to survive prune, got %v", name, err)
+		}
+	}
+}
+
+// deployedBefore marks the manifest as deployed before the other files.
+func deployedBefore(t *testing.T, dir, name string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
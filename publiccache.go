@@ -0,0 +1,107 @@
+package vite
+
+import (
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxExistsCacheEntries limits the number of paths an existsCache keeps.
+// The cache starts over when it is full, as most entries are for the few
+// routes of an app anyway.
+const maxExistsCacheEntries = 1024
+
+// existsCache caches whether files exist in a file system, for a TTL, so
+// that busy dev servers do not look up every request path in the public
+// directory. It does not watch the file system: a file that is added or
+// removed shows up after the TTL, unless the cache is invalidated, see
+// [Handler.InvalidatePublicCache].
+type existsCache struct {
+	fsys fs.FS
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]existsEntry
+}
+
+type existsEntry struct {
+	exists  bool
+	expires time.Time
+}
+
+func newExistsCache(fsys fs.FS, ttl time.Duration) *existsCache {
+	return &existsCache{
+		fsys:    fsys,
+		ttl:     ttl,
+		entries: make(map[string]existsEntry),
+	}
+}
+
+// exists returns true if the file at the URL path exists, from the cache if
+// the entry has not expired.
+func (c *existsCache) exists(path string) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.exists
+	}
+
+	exists := fileExists(c.fsys, path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxExistsCacheEntries {
+		clear(c.entries)
+	}
+	c.entries[path] = existsEntry{exists: exists, expires: now.Add(c.ttl)}
+	return exists
+}
+
+// invalidate removes the entries of the given URL paths, or all entries
+// if there are none.
+func (c *existsCache) invalidate(paths ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(paths) == 0 {
+		clear(c.entries)
+		return
+	}
+	for _, path := range paths {
+		delete(c.entries, "/"+strings.TrimPrefix(path, "/"))
+	}
+}
+
+// InvalidatePublicCache forgets whether the given files exist in the
+// public directory, or all files if there are none, so that the next
+// request looks them up again instead of waiting for PublicCacheTTL. Names
+// are relative to PublicFS, e.g. "robots.txt". Call it from a file watcher,
+// e.g. with fsnotify:
+//
+//	for event := range watcher.Events {
+//		name, _ := filepath.Rel("public", event.Name)
+//		h.InvalidatePublicCache(filepath.ToSlash(name))
+//	}
+//
+// It is a no-op if the cache is disabled.
+func (h *Handler) InvalidatePublicCache(names ...string) {
+	if h.parent != nil {
+		h.parent.InvalidatePublicCache(names...)
+		return
+	}
+	if h.pubExists != nil {
+		h.pubExists.invalidate(names...)
+	}
+}
+
+// publicFileExists returns true if the file at the URL path exists in the
+// public directory, using the cache if PublicCacheTTL is set.
+func (h *Handler) publicFileExists(path string) bool {
+	if h.pubExists != nil {
+		return h.pubExists.exists(path)
+	}
+	return fileExists(h.pub, path)
+}
@@ -0,0 +1,126 @@
+package vite
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+// Route is a route of a frontend with file-based routing, as listed in a
+// routes file. The routes file is a JSON array of routes, written by a
+// companion Vite plugin from the pages found with import.meta.glob:
+//
+//	[
+//	  {"path": "/", "entry": "src/pages/index.tsx"},
+//	  {"path": "/blog/:slug", "entry": "src/pages/blog/[slug].tsx"},
+//	  {"path": "/docs/*", "entry": "src/pages/docs/[...rest].tsx"}
+//	]
+//
+// See Config.ViteRoutes.
+type Route struct {
+	// Path is the URL path of the route. A segment of the form ":name"
+	// matches any single segment, and a final "*" matches the rest of the
+	// path. A path ending in a slash only matches the path itself.
+	Path string `json:"path"`
+
+	// Entry is the entry point of the route, e.g. "src/pages/index.tsx".
+	Entry string `json:"entry"`
+}
+
+// ParseRoutes parses a routes file.
+func ParseRoutes(r io.Reader) ([]Route, error) {
+	var routes []Route
+	if err := json.NewDecoder(r).Decode(&routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// readRoutesFile reads and parses the routes file at name in fsys.
+func readRoutesFile(fsys fs.FS, name string) ([]Route, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("vite: open routes: %w", err)
+	}
+	defer f.Close()
+
+	routes, err := ParseRoutes(f)
+	if err != nil {
+		return nil, fmt.Errorf("vite: parse routes: %w", err)
+	}
+	return routes, nil
+}
+
+// routeTable matches request paths against routes. It uses the patterns
+// of [http.ServeMux], so more specific routes take precedence, e.g.
+// "/blog/new" over "/blog/:slug".
+type routeTable struct {
+	mux     *http.ServeMux
+	entries map[string]string // by pattern
+}
+
+// newRouteTable returns a route table for the given routes.
+func newRouteTable(routes []Route) (t *routeTable, err error) {
+	t = &routeTable{
+		mux:     http.NewServeMux(),
+		entries: make(map[string]string, len(routes)),
+	}
+	for _, route := range routes {
+		pattern := routePattern(route.Path)
+		if route.Entry == "" {
+			return nil, fmt.Errorf("vite: route %q has no entry point", route.Path)
+		}
+		if _, ok := t.entries[pattern]; ok {
+			return nil, fmt.Errorf("vite: duplicate route %q", route.Path)
+		}
+		if err := t.handle(pattern); err != nil {
+			return nil, fmt.Errorf("vite: invalid route %q: %v", route.Path, err)
+		}
+		t.entries[pattern] = route.Entry
+	}
+	return t, nil
+}
+
+// handle registers the pattern with the mux, returning an error instead of
+// panicking if the pattern is invalid or conflicts with another one.
+func (t *routeTable) handle(pattern string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	t.mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// lookup returns the entry point of the route matching the request.
+func (t *routeTable) lookup(r *http.Request) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	_, pattern := t.mux.Handler(r)
+	entry, ok := t.entries[pattern]
+	return entry, ok
+}
+
+// routePattern returns the [http.ServeMux] pattern for a route path, e.g.
+// "GET /blog/{slug}" for "/blog/:slug".
+func routePattern(p string) string {
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, ":") && len(seg) > 1:
+			segments[i] = "{" + seg[1:] + "}"
+		case seg == "*" && i == len(segments)-1:
+			segments[i] = "{rest...}"
+		}
+	}
+	pattern := "/" + strings.Join(segments, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "{$}"
+	}
+	return "GET " + pattern
+}
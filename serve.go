@@ -0,0 +1,180 @@
+package vite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ServeOption configures [ListenAndServe].
+type ServeOption func(*serveOptions)
+
+type serveOptions struct {
+	ctx             context.Context
+	server          *http.Server
+	shutdownTimeout time.Duration
+	onListen        func(addr net.Addr)
+}
+
+// WithShutdownContext makes ListenAndServe shut down gracefully when ctx
+// is done. ListenAndServe always shuts down on SIGINT and SIGTERM.
+func WithShutdownContext(ctx context.Context) ServeOption {
+	return func(o *serveOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithShutdownTimeout sets the time ListenAndServe waits for active
+// connections on shutdown. It defaults to 10 seconds.
+func WithShutdownTimeout(d time.Duration) ServeOption {
+	return func(o *serveOptions) {
+		o.shutdownTimeout = d
+	}
+}
+
+// WithServer sets the server to use, e.g. to configure timeouts. The
+// handler passed to ListenAndServe is used if srv.Handler is nil.
+func WithServer(srv *http.Server) ServeOption {
+	return func(o *serveOptions) {
+		o.server = srv
+	}
+}
+
+// WithOnListen sets a function that is called with the address the server
+// listens on, e.g. to log it.
+func WithOnListen(f func(addr net.Addr)) ServeOption {
+	return func(o *serveOptions) {
+		o.onListen = f
+	}
+}
+
+// ListenAndServe listens on addr, as described in [Listen], and serves
+// requests with h. It shuts down gracefully on SIGINT and SIGTERM, and
+// returns nil after a graceful shutdown.
+//
+// Example:
+//
+//	err := vite.ListenAndServe("localhost:8080", h,
+//		vite.WithOnListen(func(addr net.Addr) {
+//			log.Printf("Listening on http://%s", addr)
+//		}),
+//	)
+func ListenAndServe(addr string, h http.Handler, opts ...ServeOption) error {
+	o := serveOptions{
+		ctx:             context.Background(),
+		shutdownTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	srv := o.server
+	if srv == nil {
+		srv = &http.Server{}
+	}
+	if srv.Handler == nil {
+		srv.Handler = h
+	}
+
+	ln, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	if o.onListen != nil {
+		o.onListen(ln.Addr())
+	}
+
+	ctx, stop := signal.NotifyContext(o.ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), o.shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("vite: shutdown: %w", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Listen announces on the given address. It supports:
+//
+//   - "unix:/path/to/socket" for a unix domain socket. A stale socket file
+//     from a previous run is removed first.
+//   - "systemd" for the first socket passed by systemd socket activation.
+//   - "localhost:port" for the IPv4 loopback interface, falling back to the
+//     IPv6 loopback interface if IPv4 is not available.
+//   - any other TCP address, e.g. ":8080", which listens on both IPv4 and
+//     IPv6 where available.
+func Listen(addr string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		return listenUnix(strings.TrimPrefix(addr, "unix:"))
+	case addr == "systemd":
+		return listenSystemd()
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("vite: invalid address %q: %w", addr, err)
+	}
+	if host != "localhost" {
+		return net.Listen("tcp", addr)
+	}
+	ln, err := net.Listen("tcp4", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		var err1 error
+		if ln, err1 = net.Listen("tcp6", net.JoinHostPort("::1", port)); err1 != nil {
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// listenUnix listens on a unix domain socket.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("vite: removing stale socket: %w", err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// listenSystemd returns the first socket passed by systemd socket
+// activation, see sd_listen_fds(3).
+func listenSystemd() (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, errors.New("vite: no sockets passed by systemd")
+	}
+	if n, err := strconv.Atoi(os.Getenv("LISTEN_FDS")); err != nil || n < 1 {
+		return nil, errors.New("vite: no sockets passed by systemd")
+	}
+	// Passed file descriptors start at 3 (SD_LISTEN_FDS_START).
+	f := os.NewFile(3, "systemd")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("vite: systemd socket: %w", err)
+	}
+	return ln, nil
+}
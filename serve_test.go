@@ -0,0 +1,93 @@
+package vite_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/olivere/vite"
+)
+
+func TestListenAndServe(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+
+	tests := []struct {
+		name   string
+		addr   string
+		client func(addr net.Addr) (*http.Client, string)
+	}{
+		{
+			name: "tcp",
+			addr: "localhost:0",
+			client: func(addr net.Addr) (*http.Client, string) {
+				return http.DefaultClient, "http://" + addr.String()
+			},
+		},
+		{
+			name: "unix",
+			addr: "unix:" + filepath.Join(t.TempDir(), "vite.sock"),
+			client: func(addr net.Addr) (*http.Client, string) {
+				return &http.Client{
+					Transport: &http.Transport{
+						DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+							var d net.Dialer
+							return d.DialContext(ctx, "unix", addr.String())
+						},
+					},
+				}, "http://unix"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			listening := make(chan net.Addr, 1)
+			done := make(chan error, 1)
+			go func() {
+				done <- vite.ListenAndServe(tt.addr, h,
+					vite.WithShutdownContext(ctx),
+					vite.WithOnListen(func(addr net.Addr) { listening <- addr }),
+				)
+			}()
+
+			var addr net.Addr
+			select {
+			case addr = <-listening:
+			case err := <-done:
+				t.Fatal(err)
+			}
+
+			client, url := tt.client(addr)
+			resp, err := client.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if want, have := "ok", string(body); want != have {
+				t.Fatalf("expected body %q, got %q", want, have)
+			}
+
+			cancel()
+			if err := <-done; err != nil {
+				t.Fatalf("expected graceful shutdown, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListenSystemdWithoutSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+	if _, err := vite.Listen("systemd"); err == nil {
+		t.Fatal("expected an error without sockets passed by systemd")
+	}
+}
@@ -0,0 +1,133 @@
+package vite
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// SSRRenderer renders the server-side HTML of a page, e.g. by calling into
+// the SSR entry of a Vite app running in Node. See the [ssr] package for
+// ready-made implementations.
+//
+// The rendered HTML is made available to templates as {{ .SSR }}.
+//
+// [ssr]: https://pkg.go.dev/github.com/olivere/vite/ssr
+type SSRRenderer interface {
+	// Render returns the HTML for the given URL. The URL is the request URI
+	// of the page, e.g. "/about?tab=team".
+	Render(ctx context.Context, url string) (string, error)
+}
+
+// SSRRendererFunc is an adapter to allow the use of ordinary functions as
+// an [SSRRenderer].
+type SSRRendererFunc func(ctx context.Context, url string) (string, error)
+
+// Render calls f(ctx, url).
+func (f SSRRendererFunc) Render(ctx context.Context, url string) (string, error) {
+	return f(ctx, url)
+}
+
+// SSRRequestRenderer is an [SSRRenderer] that renders pages with a snapshot
+// of the request, e.g. to render the page for the signed-in user, and can
+// return headers for the response, e.g. Set-Cookie. The handler prefers
+// RenderRequest over Render if the renderer implements it.
+type SSRRequestRenderer interface {
+	SSRRenderer
+
+	// RenderRequest renders the page for the given request snapshot.
+	RenderRequest(ctx context.Context, req SSRRequest) (*SSRResult, error)
+}
+
+// SSRRequest is the snapshot of a request passed to an
+// [SSRRequestRenderer]. It only contains the headers and cookies on the
+// allowlists of the configuration, see Config.SSRHeaders and
+// Config.SSRCookies, so that credentials do not leak to the renderer by
+// accident.
+type SSRRequest struct {
+	// URL is the request URI of the page, e.g. "/about?tab=team".
+	URL string `json:"url"`
+
+	// Method is the method of the request, e.g. "GET".
+	Method string `json:"method,omitempty"`
+
+	// Headers are the allowed headers of the request, by canonical name.
+	// Multiple values of a header are joined by ", ".
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Cookies are the allowed cookies of the request, by name.
+	Cookies map[string]string `json:"cookies,omitempty"`
+}
+
+// SSRResult is the result of an [SSRRequestRenderer].
+type SSRResult struct {
+	// HTML is the server-rendered HTML of the page.
+	HTML string
+
+	// Headers are added to the response, e.g. Set-Cookie or Cache-Control.
+	// Headers that describe the body or the connection, e.g. Content-Type
+	// or Content-Length, are ignored.
+	Headers http.Header
+}
+
+// ssrIgnoredHeaders are the headers of an SSRResult the handler does not
+// add to the response, as it controls them itself.
+var ssrIgnoredHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Keep-Alive":        true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// ssrRequest returns the snapshot of r for an SSRRequestRenderer, with the
+// headers and cookies on the allowlists only.
+func (h *Handler) ssrRequest(r *http.Request) SSRRequest {
+	req := SSRRequest{
+		URL:    r.URL.RequestURI(),
+		Method: r.Method,
+	}
+	for _, name := range h.ssrHeaders {
+		if values := r.Header.Values(name); len(values) > 0 {
+			if req.Headers == nil {
+				req.Headers = make(map[string]string)
+			}
+			req.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	for _, name := range h.ssrCookies {
+		if c, err := r.Cookie(name); err == nil {
+			if req.Cookies == nil {
+				req.Cookies = make(map[string]string)
+			}
+			req.Cookies[name] = c.Value
+		}
+	}
+	return req
+}
+
+// renderSSR renders the page for r with the SSR renderer, and adds the
+// headers it returns to the response.
+func (h *Handler) renderSSR(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, error) {
+	rr, ok := h.ssr.(SSRRequestRenderer)
+	if !ok {
+		return h.ssr.Render(ctx, r.URL.RequestURI())
+	}
+	res, err := rr.RenderRequest(ctx, h.ssrRequest(r))
+	if err != nil || res == nil {
+		return "", err
+	}
+	for name, values := range res.Headers {
+		name = http.CanonicalHeaderKey(name)
+		if ssrIgnoredHeaders[name] {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	return res.HTML, nil
+}
@@ -0,0 +1,76 @@
+package ssr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/olivere/vite"
+)
+
+// HTTPRenderer renders pages by posting the URL to a Node server that runs
+// the SSR entry of the Vite app.
+//
+// The server is expected to accept a JSON request body of the form
+// {"url":"/about"} and respond with {"html":"..."} (or {"error":"..."}).
+// With [HTTPRenderer.RenderRequest], the request body has the fields of
+// [vite.SSRRequest], and the response may have headers for the response of
+// the page, e.g. {"html":"...","headers":{"Set-Cookie":["seen=1"]}}.
+//
+// HTTPRenderer implements [vite.SSRRenderer] and [vite.SSRRequestRenderer].
+type HTTPRenderer struct {
+	// URL is the endpoint of the Node server, e.g. "http://localhost:13714/render".
+	URL string
+
+	// Client is the HTTP client to use. It defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Render renders the page for the given URL.
+func (hr *HTTPRenderer) Render(ctx context.Context, url string) (string, error) {
+	res, err := hr.RenderRequest(ctx, vite.SSRRequest{URL: url})
+	if err != nil {
+		return "", err
+	}
+	return res.HTML, nil
+}
+
+// RenderRequest renders the page for the given request snapshot.
+func (hr *HTTPRenderer) RenderRequest(ctx context.Context, snapshot vite.SSRRequest) (*vite.SSRResult, error) {
+	data, err := json.Marshal(request{SSRRequest: snapshot})
+	if err != nil {
+		return nil, fmt.Errorf("ssr: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hr.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("ssr: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := hr.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ssr: render %q: %w", snapshot.URL, err)
+	}
+	defer res.Body.Close()
+
+	var resp response
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("ssr: decode response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("ssr: render %q: %s", snapshot.URL, resp.Error)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ssr: render %q: unexpected status %d", snapshot.URL, res.StatusCode)
+	}
+	return resp.result(), nil
+}
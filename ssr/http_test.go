@@ -0,0 +1,81 @@
+package ssr_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/olivere/vite"
+	"github.com/olivere/vite/ssr"
+)
+
+func TestHTTPRenderer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			URL string `json:"url"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.URL == "/boom" {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "boom"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"html": "<p>" + req.URL + "</p>"})
+	}))
+	defer srv.Close()
+
+	r := &ssr.HTTPRenderer{URL: srv.URL}
+
+	html, err := r.Render(context.Background(), "/about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>/about</p>"; html != want {
+		t.Fatalf("expected %q, got %q", want, html)
+	}
+
+	_, err = r.Render(context.Background(), "/boom")
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected error containing %q, got %v", "boom", err)
+	}
+}
+
+func TestHTTPRendererRenderRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req vite.SSRRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"html":    "<p>" + req.Method + " " + req.URL + " " + req.Cookies["user"] + "</p>",
+			"headers": map[string][]string{"Set-Cookie": {"seen=1"}},
+		})
+	}))
+	defer srv.Close()
+
+	r := &ssr.HTTPRenderer{URL: srv.URL}
+
+	res, err := r.RenderRequest(context.Background(), vite.SSRRequest{
+		URL:     "/about",
+		Method:  http.MethodGet,
+		Cookies: map[string]string{"user": "alice"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>GET /about alice</p>"; res.HTML != want {
+		t.Fatalf("expected %q, got %q", want, res.HTML)
+	}
+	if want, have := "seen=1", res.Headers.Get("Set-Cookie"); want != have {
+		t.Fatalf("expected Set-Cookie %q, got %q", want, have)
+	}
+}
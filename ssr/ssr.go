@@ -0,0 +1,281 @@
+/*
+Package ssr implements server-side rendering for Vite apps with a Go backend.
+
+The package provides two implementations of [vite.SSRRenderer]:
+
+  - [Process] spawns a Node process that loads the SSR entry of the Vite app
+    (as built by "vite build --ssr") and talks to it over stdin/stdout.
+  - [HTTPRenderer] talks to an already running Node server over HTTP.
+
+Both expect the SSR entry to export a render function (either named "render"
+or as the default export) that takes the request URL and returns the HTML
+string (or an object with an "html" property), as scaffolded by
+create-vite-extra:
+
+	export async function render(url) {
+	  return { html: renderToString(<App url={url} />) }
+	}
+
+Both also implement [vite.SSRRequestRenderer]: the render function gets the
+request snapshot as its second argument, with the headers and cookies on
+the allowlists of the handler, and can return headers for the response:
+
+	export async function render(url, { cookies }) {
+	  const user = await lookupSession(cookies.session)
+	  return {
+	    html: renderToString(<App url={url} user={user} />),
+	    headers: { 'Set-Cookie': 'seen=1; Path=/' },
+	  }
+	}
+
+Example:
+
+	p, err := ssr.Start(ssr.Config{
+		Entry: "dist/server/entry-server.js",
+	})
+	if err != nil { ... }
+	defer p.Close()
+
+	v, err := vite.NewHandler(vite.Config{
+		FS:  DistFS(),
+		SSR: p,
+	})
+
+[vite.SSRRenderer]: https://pkg.go.dev/github.com/olivere/vite#SSRRenderer
+[vite.SSRRequestRenderer]: https://pkg.go.dev/github.com/olivere/vite#SSRRequestRenderer
+*/
+package ssr
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/olivere/vite"
+)
+
+// ErrClosed is returned when rendering with a process that has exited
+// or has been closed.
+var ErrClosed = errors.New("ssr: process closed")
+
+// closeTimeout is the time Close waits for the process to exit before
+// killing it.
+const closeTimeout = 5 * time.Second
+
+//go:embed worker.mjs
+var workerScript string
+
+// Config is the configuration for a Node SSR process.
+type Config struct {
+	// Entry is the path to the SSR entry of the Vite app, e.g.
+	// "dist/server/entry-server.js". It is required.
+	Entry string
+
+	// Command is the Node executable to run. It defaults to "node".
+	Command string
+
+	// Dir is the working directory of the process. It defaults to the
+	// working directory of the calling process.
+	Dir string
+
+	// Env specifies the environment of the process. If it is nil, the
+	// process uses the environment of the calling process.
+	Env []string
+
+	// Stderr receives everything the process writes to its standard output
+	// and standard error, e.g. console.log calls in the app. It defaults
+	// to os.Stderr.
+	Stderr io.Writer
+}
+
+// Process is a Node process that renders pages by calling into the SSR
+// entry of a Vite app. Requests are processed one at a time.
+//
+// Process implements [vite.SSRRenderer] and [vite.SSRRequestRenderer].
+type Process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan response
+	exited    chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.Mutex // serializes requests
+	nextID uint64
+	err    error // set before exited is closed
+}
+
+type request struct {
+	ID uint64 `json:"id"`
+	vite.SSRRequest
+}
+
+type response struct {
+	ID      uint64              `json:"id"`
+	HTML    string              `json:"html"`
+	Headers map[string][]string `json:"headers"`
+	Error   string              `json:"error"`
+}
+
+// result returns the result of a successful response.
+func (resp response) result() *vite.SSRResult {
+	res := &vite.SSRResult{HTML: resp.HTML}
+	for name, values := range resp.Headers {
+		if res.Headers == nil {
+			res.Headers = make(http.Header)
+		}
+		for _, value := range values {
+			res.Headers.Add(name, value)
+		}
+	}
+	return res
+}
+
+// Start spawns a new Node process for the given configuration.
+func Start(config Config) (*Process, error) {
+	if config.Entry == "" {
+		return nil, errors.New("ssr: entry is empty")
+	}
+	if config.Command == "" {
+		config.Command = "node"
+	}
+	if config.Stderr == nil {
+		config.Stderr = os.Stderr
+	}
+
+	cmd := exec.Command(config.Command, "--input-type=module", "-e", workerScript, config.Entry)
+	cmd.Dir = config.Dir
+	cmd.Env = config.Env
+	cmd.Stderr = config.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("ssr: stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("ssr: stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("ssr: start process: %w", err)
+	}
+
+	p := &Process{
+		cmd:       cmd,
+		stdin:     stdin,
+		responses: make(chan response),
+		exited:    make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	go p.readLoop(stdout, config.Stderr)
+	return p, nil
+}
+
+// readLoop reads responses from the process until it exits.
+func (p *Process) readLoop(stdout io.Reader, stderr io.Writer) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+loop:
+	for scanner.Scan() {
+		var resp response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			// Not a response, e.g. output of a third-party library that
+			// writes to stdout directly. Pass it through.
+			fmt.Fprintln(stderr, scanner.Text())
+			continue
+		}
+		select {
+		case p.responses <- resp:
+		case <-p.closed:
+			break loop
+		}
+	}
+
+	err := p.cmd.Wait()
+	if err == nil {
+		err = ErrClosed
+	} else {
+		err = fmt.Errorf("ssr: process exited: %w", err)
+	}
+	p.err = err
+	close(p.exited)
+}
+
+// Render renders the page for the given URL.
+func (p *Process) Render(ctx context.Context, url string) (string, error) {
+	res, err := p.RenderRequest(ctx, vite.SSRRequest{URL: url})
+	if err != nil {
+		return "", err
+	}
+	return res.HTML, nil
+}
+
+// RenderRequest renders the page for the given request snapshot.
+func (p *Process) RenderRequest(ctx context.Context, req vite.SSRRequest) (*vite.SSRResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	select {
+	case <-p.exited:
+		return nil, p.err
+	case <-p.closed:
+		return nil, ErrClosed
+	default:
+	}
+
+	p.nextID++
+	id := p.nextID
+
+	data, err := json.Marshal(request{ID: id, SSRRequest: req})
+	if err != nil {
+		return nil, fmt.Errorf("ssr: encode request: %w", err)
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("ssr: write request: %w", err)
+	}
+
+	for {
+		select {
+		case resp := <-p.responses:
+			if resp.ID != id {
+				// Stale response of a request that was canceled.
+				continue
+			}
+			if resp.Error != "" {
+				return nil, fmt.Errorf("ssr: render %q: %s", req.URL, resp.Error)
+			}
+			return resp.result(), nil
+		case <-p.exited:
+			return nil, p.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close stops the Node process.
+func (p *Process) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		err = p.stdin.Close()
+
+		// The worker exits when stdin is closed; make sure it does.
+		select {
+		case <-p.exited:
+		case <-time.After(closeTimeout):
+			_ = p.cmd.Process.Kill()
+			<-p.exited
+		}
+	})
+	return err
+}
@@ -0,0 +1,43 @@
+// SSR worker spawned by the Go ssr package. It loads the SSR entry given
+// as the first argument and answers line-delimited JSON requests of the
+// form {"id":1,"url":"/"} on stdin with {"id":1,"html":"..."} or
+// {"id":1,"error":"..."} on stdout. The request is passed to the render
+// function as its second argument; headers it returns, e.g.
+// { "Set-Cookie": "seen=1" }, are passed on as lists of values.
+import { createInterface } from 'node:readline'
+import { resolve } from 'node:path'
+import { pathToFileURL } from 'node:url'
+
+// Keep stdout reserved for responses.
+console.log = console.info = console.debug = console.error
+
+const entry = process.argv[1]
+const mod = await import(pathToFileURL(resolve(entry)).href)
+const render = mod.render ?? mod.default
+if (typeof render !== 'function') {
+  console.error(`ssr: ${entry} does not export a render function`)
+  process.exit(1)
+}
+
+const rl = createInterface({ input: process.stdin, crlfDelay: Infinity })
+for await (const line of rl) {
+  if (!line.trim()) continue
+  let req
+  try {
+    req = JSON.parse(line)
+  } catch (err) {
+    console.error(`ssr: invalid request: ${err}`)
+    continue
+  }
+  try {
+    const out = await render(req.url, { headers: {}, cookies: {}, ...req })
+    const html = typeof out === 'string' ? out : (out?.html ?? '')
+    const headers = {}
+    for (const [name, value] of Object.entries(out?.headers ?? {})) {
+      headers[name] = (Array.isArray(value) ? value : [value]).map(String)
+    }
+    process.stdout.write(JSON.stringify({ id: req.id, html, headers }) + '\n')
+  } catch (err) {
+    process.stdout.write(JSON.stringify({ id: req.id, error: String(err?.stack ?? err) }) + '\n')
+  }
+}
@@ -0,0 +1,173 @@
+package vite
+
+import (
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// indexHTMLName is the name of the page Vite builds for the app, served by
+// the handler if Config.ServeIndexHTML is set.
+const indexHTMLName = "index.html"
+
+// titleRE matches the title element of a page.
+var titleRE = regexp.MustCompile(`(?is)<title[^>]*>.*?</title>\s*`)
+
+// headRE matches the start tag of the head element.
+var headRE = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+
+// startTagRE matches a start tag, with the attributes in the second group.
+var startTagRE = regexp.MustCompile(`(?i)<([a-z][a-z0-9-]*)(\s[^>]*)?>`)
+
+// idAttrRE matches the id attribute in the attributes of a start tag.
+var idAttrRE = regexp.MustCompile(`(?i)(?:^|\s)id\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// mountIDs are the ids of the element the app mounts into in the
+// index.html of the Vite scaffolds, e.g. "root" for React and "app" for
+// Vue, Svelte, and vanilla apps.
+var mountIDs = map[string]bool{"root": true, "app": true}
+
+// indexPage is the index.html of the Vite build, parsed once per manifest.
+type indexPage struct {
+	html      string
+	title     [2]int // position of the title element, or -1
+	headStart int    // position after <head>, or -1
+	headEnd   int    // position of </head>, or -1
+	mount     [2]int // position of the content of the mount element, or -1
+}
+
+// indexHTMLCache caches the index.html of the current Vite manifest, as
+// it only changes with the build.
+type indexHTMLCache struct {
+	mu       sync.Mutex
+	manifest *Manifest // the manifest the page was read for
+	page     *indexPage
+	read     bool
+}
+
+// staticIndex returns a function that writes the index.html of the Vite
+// build, with the metadata and scripts of the page injected, and false if
+// there is no index.html to serve. It is used instead of the fallback
+// template if Config.ServeIndexHTML is set and no templates are
+// registered.
+func (h *Handler) staticIndex() (executeFunc, bool) {
+	if h.indexHTML == nil || h.isDev || len(h.templates)+len(h.hostTemplates()) > 1 || h.engine != nil {
+		return nil, false
+	}
+	page := h.indexPage()
+	if page == nil {
+		return nil, false
+	}
+	return func(w io.Writer, data PageData) error {
+		_, err := io.WriteString(w, page.inject(data))
+		return err
+	}, true
+}
+
+// indexPage returns the index.html of the current manifest, reading it on
+// first use after the manifest was loaded. It returns nil if there is no
+// index.html.
+func (h *Handler) indexPage() *indexPage {
+	m := h.manifest.Load()
+
+	h.indexHTML.mu.Lock()
+	defer h.indexHTML.mu.Unlock()
+	if h.indexHTML.read && h.indexHTML.manifest == m {
+		return h.indexHTML.page
+	}
+
+	h.indexHTML.manifest = m
+	h.indexHTML.read = true
+	h.indexHTML.page = nil
+	data, err := fs.ReadFile(h.fs, indexHTMLName)
+	if err != nil {
+		h.logger.Warn("Unable to read index.html, using the fallback template", "error", err)
+		return nil
+	}
+	page := parseIndexPage(string(data))
+	if page.headEnd < 0 {
+		h.logger.Warn("The index.html has no </head>, metadata and scripts are not injected")
+	}
+	if page.mount[0] < 0 && (h.ssr != nil || h.bodyStream != nil) {
+		h.logger.Warn(`The index.html has no empty element with id "root" or "app", server-rendered HTML is not injected`)
+	}
+	h.indexHTML.page = page
+	return page
+}
+
+// parseIndexPage finds the positions in the page to inject into.
+func parseIndexPage(s string) *indexPage {
+	page := &indexPage{html: s, title: [2]int{-1, -1}, mount: [2]int{-1, -1}}
+	if loc := titleRE.FindStringIndex(s); loc != nil {
+		page.title = [2]int{loc[0], loc[1]}
+	}
+	page.headStart = -1
+	if loc := headRE.FindStringIndex(s); loc != nil {
+		page.headStart = loc[1]
+	}
+	page.headEnd = indexFold(s, "</head>")
+	for _, loc := range startTagRE.FindAllStringSubmatchIndex(s, -1) {
+		if loc[4] < 0 {
+			continue
+		}
+		m := idAttrRE.FindStringSubmatch(s[loc[4]:loc[5]])
+		if m == nil || !mountIDs[m[1]+m[2]+m[3]] {
+			continue
+		}
+		// Only inject into an empty element, not into a placeholder.
+		content := loc[1] + len(s[loc[1]:]) - len(strings.TrimLeft(s[loc[1]:], " \t\r\n"))
+		if end := "</" + s[loc[2]:loc[3]] + ">"; len(s)-content >= len(end) && strings.EqualFold(s[content:content+len(end)], end) {
+			page.mount = [2]int{loc[1], content}
+		}
+		break
+	}
+	return page
+}
+
+// inject injects the metadata and scripts of the page before the end of
+// the head, and the server-rendered HTML into the mount element, i.e. the
+// first element with the id "root" or "app", if it is empty. If the
+// metadata has a title, it replaces the title of the page. Otherwise, the
+// title of the page is kept. A base URL is injected at the start of the
+// head, so that it applies to the scripts and stylesheets of the page.
+func (p *indexPage) inject(page PageData) string {
+	var edits []indexEdit
+	meta := string(page.Metadata)
+	if md := page.md; md != nil && p.title[0] >= 0 {
+		if md.title() != "" {
+			edits = append(edits, indexEdit{p.title[0], p.title[1], ""})
+		} else {
+			meta = strings.Replace(meta, md.titleTag(), "", 1)
+		}
+	}
+	if md := page.md; md != nil && md.Base != "" && p.headStart >= 0 {
+		meta = strings.Replace(meta, md.baseTag(), "", 1)
+		edits = append(edits, indexEdit{p.headStart, p.headStart, "\n" + md.baseTag()})
+	}
+	if head := meta + string(page.Scripts); head != "" && p.headEnd >= 0 {
+		edits = append(edits, indexEdit{p.headEnd, p.headEnd, head + "\n"})
+	}
+	if page.SSR != "" && p.mount[0] >= 0 {
+		edits = append(edits, indexEdit{p.mount[0], p.mount[1], string(page.SSR)})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var sb strings.Builder
+	pos := 0
+	for _, e := range edits {
+		sb.WriteString(p.html[pos:e.start])
+		sb.WriteString(e.text)
+		pos = e.end
+	}
+	sb.WriteString(p.html[pos:])
+	return sb.String()
+}
+
+// indexEdit replaces the text between start and end of the index.html.
+type indexEdit struct {
+	start, end int
+	text       string
+}
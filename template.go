@@ -0,0 +1,16 @@
+package vite
+
+import "io"
+
+// TemplateEngine renders pages with a template engine other than
+// html/template, e.g. jet, pongo2, or quicktemplate. The engine receives the
+// same [PageData] that is passed to templates registered with
+// [Handler.RegisterTemplate].
+type TemplateEngine interface {
+	// Lookup returns true if the engine has a template with the given name.
+	// See [Handler.RegisterTemplate] for how names map to URL paths.
+	Lookup(name string) bool
+
+	// Execute renders the template with the given name to w.
+	Execute(w io.Writer, name string, data PageData) error
+}
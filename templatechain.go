@@ -0,0 +1,90 @@
+package vite
+
+import (
+	"io"
+	"strings"
+)
+
+// TemplateChainFunc returns the names of the templates to try, in order,
+// for the page at the given URL path, e.g. "/admin/users". The handler
+// renders the page with the first template that is registered, and with
+// the built-in fallback template if there is none. See
+// [DefaultTemplateChain].
+type TemplateChainFunc func(path string) []string
+
+// DefaultTemplateChain returns the template chain of the path: the route
+// itself, its sections from the most specific one, and the index. For
+// "/admin/users", it returns "/admin/users", "/admin", and "index.html".
+func DefaultTemplateChain(path string) []string {
+	path = "/" + strings.Trim(path, "/")
+	if path == "/" || path == "/index.html" {
+		return []string{"index.html"}
+	}
+	var chain []string
+	for p := path; p != ""; p = p[:strings.LastIndex(p, "/")] {
+		chain = append(chain, p)
+	}
+	return append(chain, "index.html")
+}
+
+// templateSpellings returns the names a template of the chain may be
+// registered with, e.g. "/about", "about", "about.html", and "/about.html"
+// for "/about". The name itself comes first.
+func templateSpellings(name string) []string {
+	base := strings.TrimSuffix(strings.TrimPrefix(name, "/"), ".html")
+	spellings := []string{name}
+	for _, s := range []string{"/" + base, base, base + ".html", "/" + base + ".html"} {
+		if s != name {
+			spellings = append(spellings, s)
+		}
+	}
+	return spellings
+}
+
+// isIndexTemplate returns true if name is a spelling of the index template.
+func isIndexTemplate(name string) bool {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "/"), ".html") == "index"
+}
+
+// templateChain returns the template chain of the path.
+func (h *Handler) templateChain(path string) []string {
+	if h.chain != nil {
+		return h.chain(path)
+	}
+	return DefaultTemplateChain(path)
+}
+
+// findChainTemplate returns the registered name of the first template of
+// the chain of path, with its position in the chain. It returns false if
+// no template of the chain is registered.
+func (h *Handler) findChainTemplate(path string) (name string, pos int, ok bool) {
+	for i, candidate := range h.templateChain(path) {
+		for _, name := range templateSpellings(candidate) {
+			if h.hasTemplate(name) {
+				return name, i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// hasSectionTemplate returns true if the chain of path has a template other
+// than the index, e.g. "/admin" for "/admin/users".
+func (h *Handler) hasSectionTemplate(path string) bool {
+	name, _, ok := h.findChainTemplate(path)
+	return ok && !isIndexTemplate(name)
+}
+
+// executeTemplate returns a func that executes the registered template with
+// the given name, in the template engine or with html/template.
+func (h *Handler) executeTemplate(name string) executeFunc {
+	if h.engine != nil && h.engine.Lookup(name) {
+		return func(w io.Writer, page PageData) error {
+			return h.engine.Execute(w, name, page)
+		}
+	}
+	tmpl, _ := h.findTemplate(name)
+	return func(w io.Writer, page PageData) error {
+		return tmpl.Execute(w, page)
+	}
+}
@@ -0,0 +1,108 @@
+package vite
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RegisterTemplatesFS registers the files in fsys that match any of the
+// patterns (see [fs.Glob]) as templates. Each template is registered
+// under the URL path derived from its file path, without the extension:
+// "about.html" is served at "/about", "docs/intro.tmpl" at "/docs/intro",
+// and "docs/index.html" at "/docs". The file "index.html" is used for the
+// root URL ("/").
+//
+// Use [fs.Sub] to register the templates of a subdirectory:
+//
+//	pages, _ := fs.Sub(templates, "pages")
+//	err := h.RegisterTemplatesFS(pages, "*.html", "*/*.html")
+//
+// Unlike [Handler.RegisterTemplate], it returns an error if a template
+// cannot be parsed or is already registered, and an error wrapping
+// [ErrTemplateNotFound] if the patterns match no files.
+func (h *Handler) RegisterTemplatesFS(fsys fs.FS, patterns ...string) error {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return fmt.Errorf("vite: invalid template pattern %q: %w", pattern, err)
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("vite: no files match %q: %w", patterns, ErrTemplateNotFound)
+	}
+	sort.Strings(files)
+
+	parsed := make(map[string]*template.Template, len(files))
+	sources := make(map[string]templateFile, len(files))
+	for _, file := range files {
+		name := templateNameForFile(file)
+		if _, ok := h.templates[name]; ok || parsed[name] != nil {
+			return fmt.Errorf("vite: template %q for %s already registered", name, file)
+		}
+		src := templateFile{fsys: fsys, file: file}
+		tmpl, err := h.parseTemplateFile(name, src)
+		if err != nil {
+			return err
+		}
+		parsed[name] = tmpl
+		sources[name] = src
+	}
+
+	if h.templates == nil {
+		h.templates = make(map[string]*template.Template)
+	}
+	if h.templateFiles == nil {
+		h.templateFiles = make(map[string]templateFile)
+	}
+	for name, tmpl := range parsed {
+		h.templates[name] = tmpl
+		h.templateFiles[name] = sources[name]
+	}
+	return nil
+}
+
+// templateFile is the source of a template registered from a file.
+type templateFile struct {
+	fsys fs.FS
+	file string
+}
+
+// parseTemplateFile reads and parses the template with the given name
+// from its file.
+func (h *Handler) parseTemplateFile(name string, src templateFile) (*template.Template, error) {
+	data, err := fs.ReadFile(src.fsys, src.file)
+	if err != nil {
+		return nil, fmt.Errorf("vite: read template %s: %w", src.file, err)
+	}
+	tmpl, err := template.New(name).Funcs(h.templateFuncs()).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("vite: parse template %s: %w", src.file, err)
+	}
+	return tmpl, nil
+}
+
+// templateNameForFile returns the name of the template for a file, i.e.
+// the URL path it is served at.
+func templateNameForFile(file string) string {
+	name := strings.TrimSuffix(file, path.Ext(file))
+	switch {
+	case name == "index":
+		return "index.html"
+	case path.Base(name) == "index":
+		return "/" + path.Dir(name)
+	default:
+		return "/" + name
+	}
+}
@@ -0,0 +1,68 @@
+package vite
+
+import "context"
+
+// Tracer starts spans for tracing the handler, e.g. with OpenTelemetry.
+// The package does not depend on a tracing library; implement Tracer with
+// a small adapter instead:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) StartSpan(ctx context.Context, name string, attrs ...vite.SpanAttribute) (context.Context, vite.Span) {
+//		ctx, span := t.Start(ctx, name, trace.WithAttributes(otelAttributes(attrs)...))
+//		return ctx, otelSpan{span}
+//	}
+//
+// The handler starts these spans:
+//
+//   - "vite.render_page" around rendering a page, with the attributes
+//     "vite.path", "vite.entry", and "vite.template".
+//   - "vite.manifest_lookup" around resolving the entry points of a page
+//     in the manifest, with the attribute "vite.entries".
+//   - "vite.ssr" around server-side rendering a page, with the attribute
+//     "vite.url".
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span)
+}
+
+// Span is a span started by a [Tracer].
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...SpanAttribute)
+	// RecordError records an error of the operation.
+	RecordError(err error)
+	// End ends the span.
+	End()
+}
+
+// SpanAttribute is an attribute of a span.
+type SpanAttribute struct {
+	Key   string
+	Value string
+}
+
+// spanKey is the context key of the render span of a request.
+var spanKey = contextKey("span")
+
+// startSpan starts a span with the configured tracer, if any.
+func (h *Handler) startSpan(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span) {
+	if h.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return h.tracer.StartSpan(ctx, name, attrs...)
+}
+
+// spanFromContext returns the render span of a request.
+func spanFromContext(ctx context.Context) Span {
+	if span, ok := ctx.Value(spanKey).(Span); ok {
+		return span
+	}
+	return noopSpan{}
+}
+
+// noopSpan is the span used if no tracer is configured.
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...SpanAttribute) {}
+func (noopSpan) RecordError(error)              {}
+func (noopSpan) End()                           {}
@@ -0,0 +1,243 @@
+package vite
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// WriteTypeScriptDefinitions writes a TypeScript declaration file (.d.ts)
+// for the data written into pages with [ClientEnvScript], generated from
+// the Go type of env, so that the frontend reads it type-safe. It declares
+// an interface for each named struct type, with the fields as encoded by
+// encoding/json, and types readEnv of the client helper and
+// window.__vite_go with it:
+//
+//	type Settings struct {
+//		API      string   `json:"api"`
+//		Features []string `json:"features,omitempty"`
+//	}
+//
+//	err := vite.WriteTypeScriptDefinitions(f, Settings{})
+//
+// writes, among the declarations of the client helper:
+//
+//	interface Settings {
+//	  api: string;
+//	  features?: string[] | null;
+//	}
+//
+// The declarations are global, so the file has to be included in the
+// TypeScript project, e.g. as "src/vite-go-env.d.ts". Run it with go
+// generate to keep Go and TypeScript in sync.
+func WriteTypeScriptDefinitions(w io.Writer, env any) error {
+	t := reflect.TypeOf(env)
+	if t == nil {
+		return errors.New("vite: typescript definitions: env is nil")
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	g := &tsGenerator{names: make(map[reflect.Type]string), used: make(map[string]bool)}
+	root := g.typeOf(t)
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by vite.WriteTypeScriptDefinitions. DO NOT EDIT.\n")
+	for i := 0; i < len(g.queue); i++ {
+		t := g.queue[i]
+		sb.WriteString("\ninterface ")
+		sb.WriteString(g.names[t])
+		sb.WriteString(" {\n")
+		for _, f := range g.fields(t) {
+			sb.WriteString("  ")
+			sb.WriteString(f)
+			sb.WriteString(";\n")
+		}
+		sb.WriteString("}\n")
+	}
+	fmt.Fprintf(&sb, tsClientDeclarations, ClientPath, root)
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("vite: write typescript definitions: %w", err)
+	}
+	return nil
+}
+
+const tsClientDeclarations = `
+declare module %[1]q {
+  export function onFullReload(cb: (payload: unknown) => void): void;
+  export function showErrorOverlay(err: string | { message: string; stack?: string }): void;
+  export function readEnv(id?: string): %[2]s;
+}
+
+interface Window {
+  __vite_go: typeof import(%[1]q);
+}
+`
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// tsGenerator generates TypeScript types for Go types.
+type tsGenerator struct {
+	names map[reflect.Type]string // interface names of named structs
+	used  map[string]bool         // interface names in use
+	queue []reflect.Type          // named structs to declare, in order
+}
+
+// typeOf returns the TypeScript type of the JSON encoding of t.
+func (g *tsGenerator) typeOf(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "string"
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return "unknown"
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Pointer:
+		return g.typeOf(t.Elem()) + " | null"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as a base64 string.
+			return "string"
+		}
+		return g.arrayOf(t.Elem()) + " | null"
+	case reflect.Array:
+		return g.arrayOf(t.Elem())
+	case reflect.Map:
+		return "Record<string, " + g.typeOf(t.Elem()) + "> | null"
+	case reflect.Struct:
+		if t.Name() == "" {
+			return "{ " + strings.Join(g.fields(t), "; ") + " }"
+		}
+		return g.declare(t)
+	default:
+		return "unknown"
+	}
+}
+
+// arrayOf returns the TypeScript array type of elements of type t.
+func (g *tsGenerator) arrayOf(t reflect.Type) string {
+	elem := g.typeOf(t)
+	if strings.ContainsAny(elem, " |") {
+		elem = "(" + elem + ")"
+	}
+	return elem + "[]"
+}
+
+// declare returns the interface name of the named struct t, and queues
+// its declaration the first time.
+func (g *tsGenerator) declare(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	// Generic types have names like "Page[main.User]".
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, t.Name())
+	name := base
+	for i := 2; g.used[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.used[name] = true
+	g.names[t] = name
+	g.queue = append(g.queue, t)
+	return name
+}
+
+// fields returns the TypeScript properties of the struct t, following the
+// rules of encoding/json for names, omitted and embedded fields.
+func (g *tsGenerator) fields(t reflect.Type) []string {
+	var props []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				props = append(props, g.fields(ft)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		typ := g.typeOf(f.Type)
+		optional := false
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty", "omitzero":
+				optional = true
+			case "string":
+				switch typ {
+				case "boolean", "number", "string":
+					typ = "string"
+				}
+			}
+		}
+
+		prop := name
+		if !isTSIdentifier(name) {
+			quoted, _ := json.Marshal(name)
+			prop = string(quoted)
+		}
+		if optional {
+			prop += "?"
+		}
+		props = append(props, prop+": "+typ)
+	}
+	return props
+}
+
+// isTSIdentifier returns true if s can be used as a property name without
+// quotes.
+func isTSIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
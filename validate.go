@@ -0,0 +1,84 @@
+package vite
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+var (
+	// ErrMissingFile indicates that a chunk in the manifest has no file.
+	ErrMissingFile = errors.New("missing file")
+
+	// ErrUnresolvedImport indicates that a chunk imports a chunk that is
+	// not in the manifest.
+	ErrUnresolvedImport = errors.New("unresolved import")
+)
+
+// ChunkError records an error in a chunk of the manifest.
+type ChunkError struct {
+	// Key is the key of the chunk in the manifest.
+	Key string
+
+	// Err is the underlying error, e.g. [ErrMissingFile],
+	// [ErrUnresolvedImport], or an error wrapping [fs.ErrNotExist].
+	Err error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("vite: chunk %q: %v", e.Key, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the manifest for consistency. It verifies that every
+// chunk has a file, that all imports and dynamic imports resolve to chunks
+// in the manifest, and, if fsys is not nil, that all files, stylesheets,
+// and assets referenced by the chunks exist in fsys.
+//
+// The returned error joins a [*ChunkError] for each problem found, ordered
+// by chunk key. Use [errors.As] to inspect them, or [errors.Is] to check for
+// a particular kind of problem.
+func (m Manifest) Validate(fsys fs.FS) error {
+	var errs []error
+	for _, key := range m.keys() {
+		chunk := m[key]
+		if chunk == nil {
+			errs = append(errs, &ChunkError{Key: key, Err: ErrMissingFile})
+			continue
+		}
+
+		if chunk.File == "" {
+			errs = append(errs, &ChunkError{Key: key, Err: ErrMissingFile})
+		}
+		for _, imp := range chunk.Imports {
+			if _, ok := m[imp]; !ok {
+				errs = append(errs, &ChunkError{Key: key, Err: fmt.Errorf("%w %q", ErrUnresolvedImport, imp)})
+			}
+		}
+		for _, imp := range chunk.DynamicImports {
+			if _, ok := m[imp]; !ok {
+				errs = append(errs, &ChunkError{Key: key, Err: fmt.Errorf("%w %q", ErrUnresolvedImport, imp)})
+			}
+		}
+
+		if fsys == nil {
+			continue
+		}
+		files := make([]string, 0, 1+len(chunk.CSS)+len(chunk.Assets))
+		if chunk.File != "" {
+			files = append(files, chunk.File)
+		}
+		files = append(files, chunk.CSS...)
+		files = append(files, chunk.Assets...)
+		for _, file := range files {
+			if _, err := fs.Stat(fsys, path.Clean(file)); err != nil {
+				errs = append(errs, &ChunkError{Key: key, Err: fmt.Errorf("file %q: %w", file, err)})
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,314 @@
+package vite
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrUnclosedTag indicates that an element of a page is not closed.
+	ErrUnclosedTag = errors.New("unclosed tag")
+
+	// ErrStrayEndTag indicates an end tag without a matching start tag.
+	ErrStrayEndTag = errors.New("stray end tag")
+
+	// ErrDuplicateID indicates that several elements of a page have the
+	// same id, e.g. two #root elements.
+	ErrDuplicateID = errors.New("duplicate id")
+
+	// ErrMisplacedScript indicates a script outside of the <head> and
+	// <body> elements of a page.
+	ErrMisplacedScript = errors.New("script outside head and body")
+)
+
+// HTMLError records a problem in a rendered page.
+type HTMLError struct {
+	// Line is the line of the problem, starting at 1.
+	Line int
+
+	// Err is the problem, e.g. [ErrUnclosedTag], [ErrStrayEndTag],
+	// [ErrDuplicateID], or [ErrMisplacedScript].
+	Err error
+}
+
+func (e *HTMLError) Error() string {
+	return fmt.Sprintf("vite: html line %d: %v", e.Line, e.Err)
+}
+
+func (e *HTMLError) Unwrap() error {
+	return e.Err
+}
+
+// voidElements are the elements without an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// optionalEndElements are the elements whose end tag may be omitted.
+var optionalEndElements = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true,
+	"dt": true, "dd": true, "tr": true, "td": true, "th": true,
+	"thead": true, "tbody": true, "tfoot": true, "option": true,
+	"optgroup": true, "colgroup": true, "caption": true, "rp": true,
+	"rt": true,
+}
+
+// rawTextElements are the elements whose content is not parsed as HTML.
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+}
+
+// ValidateHTML checks a rendered page for problems that are easy to
+// introduce when composing templates and injected tags: elements that are
+// not closed, end tags without a start tag, duplicate ids, and scripts
+// outside of <head> and <body>. It is no full HTML parser and allows the
+// end tags HTML allows to omit, e.g. </p> or </li>.
+//
+// The returned error joins an [*HTMLError] for each problem found, ordered
+// by line. Use [errors.As] to inspect them, or [errors.Is] to check for a
+// particular kind of problem.
+func ValidateHTML(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("vite: read html: %w", err)
+	}
+	return validateHTML(string(data))
+}
+
+// htmlElement is an open element while validating a page.
+type htmlElement struct {
+	name string
+	pos  int
+}
+
+func validateHTML(s string) error {
+	var (
+		problems []*HTMLError
+		stack    []htmlElement
+		ids      = make(map[string]bool)
+		document bool // whether the page has html, head, or body elements
+	)
+	report := func(pos int, err error) {
+		problems = append(problems, &HTMLError{Line: strings.Count(s[:pos], "\n") + 1, Err: err})
+	}
+	inHeadOrBody := func() bool {
+		for _, el := range stack {
+			if el.name == "head" || el.name == "body" {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < len(s); {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			break
+		}
+		pos := i + lt
+		rest := s[pos:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				i = len(s)
+				continue
+			}
+			i = pos + 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"):
+			i = pos + tagEnd(rest)
+
+		case strings.HasPrefix(rest, "</"):
+			name, _ := tagName(rest[2:])
+			i = pos + tagEnd(rest)
+			if name == "" {
+				continue
+			}
+			n := len(stack) - 1
+			for n >= 0 && stack[n].name != name {
+				n--
+			}
+			if n < 0 {
+				if !optionalEndElements[name] {
+					report(pos, fmt.Errorf("%w </%s>", ErrStrayEndTag, name))
+				}
+				continue
+			}
+			for _, el := range stack[n+1:] {
+				if !optionalEndElements[el.name] {
+					report(el.pos, fmt.Errorf("%w <%s>", ErrUnclosedTag, el.name))
+				}
+			}
+			stack = stack[:n]
+
+		default:
+			name, n := tagName(rest[1:])
+			if name == "" {
+				i = pos + 1
+				continue
+			}
+			attrs, selfClosing, m := tagAttributes(rest[1+n:])
+			i = pos + 1 + n + m
+
+			switch name {
+			case "html", "head", "body":
+				document = true
+			}
+			if name == "body" && len(stack) > 0 && stack[len(stack)-1].name == "head" {
+				stack = stack[:len(stack)-1]
+			}
+			if name == "script" && document && !inHeadOrBody() {
+				report(pos, ErrMisplacedScript)
+			}
+			if id, ok := attrs["id"]; ok && id != "" {
+				if ids[id] {
+					report(pos, fmt.Errorf("%w %q", ErrDuplicateID, id))
+				}
+				ids[id] = true
+			}
+
+			if voidElements[name] || selfClosing {
+				continue
+			}
+			if rawTextElements[name] {
+				end := indexFold(s[i:], "</"+name)
+				if end < 0 {
+					report(pos, fmt.Errorf("%w <%s>", ErrUnclosedTag, name))
+					i = len(s)
+					continue
+				}
+				i += end + tagEnd(s[i+end:])
+				continue
+			}
+			stack = append(stack, htmlElement{name: name, pos: pos})
+		}
+	}
+	for _, el := range stack {
+		if !optionalEndElements[el.name] {
+			report(el.pos, fmt.Errorf("%w <%s>", ErrUnclosedTag, el.name))
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	errs := make([]error, len(problems))
+	for i, p := range problems {
+		errs[i] = p
+	}
+	return errors.Join(errs...)
+}
+
+// tagName returns the lower-case name of the tag at the start of s, and its
+// length. The name is empty if s does not start with a letter.
+func tagName(s string) (string, int) {
+	n := 0
+	for n < len(s) {
+		c := s[n]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '/' || c == '>' {
+			break
+		}
+		if n == 0 && !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return "", 0
+		}
+		n++
+	}
+	return strings.ToLower(s[:n]), n
+}
+
+// tagEnd returns the length of the tag at the start of s, up to and
+// including the closing '>', or len(s) if it is not closed.
+func tagEnd(s string) int {
+	if n := strings.IndexByte(s, '>'); n >= 0 {
+		return n + 1
+	}
+	return len(s)
+}
+
+// tagAttributes parses the attributes of a start tag after its name. It
+// returns the attributes by lower-case name, whether the tag is
+// self-closing, and the length up to and including the closing '>'.
+func tagAttributes(s string) (map[string]string, bool, int) {
+	attrs := make(map[string]string)
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+	}
+	i := 0
+	for i < len(s) {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		switch {
+		case s[i] == '>':
+			return attrs, false, i + 1
+		case strings.HasPrefix(s[i:], "/>"):
+			return attrs, true, i + 2
+		case s[i] == '/':
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(s[start:i])
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) || s[i] != '=' {
+			attrs[name] = ""
+			continue
+		}
+		i++
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+			quote := s[i]
+			end := strings.IndexByte(s[i+1:], quote)
+			if end < 0 {
+				return attrs, false, len(s)
+			}
+			attrs[name] = s[i+1 : i+1+end]
+			i += end + 2
+			continue
+		}
+		start = i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+			i++
+		}
+		attrs[name] = s[start:i]
+	}
+	return attrs, false, len(s)
+}
+
+// indexFold returns the index of the first case-insensitive occurrence of
+// the ASCII string substr in s, or -1.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkPage logs a warning if a rendered page has problems.
+func (h *Handler) checkPage(r *http.Request, page []byte) {
+	if err := validateHTML(string(page)); err != nil {
+		h.logger.Warn(
+			"Rendered page has invalid HTML",
+			"url", r.URL.RequestURI(),
+			"error", err,
+		)
+	}
+}
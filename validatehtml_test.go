@@ -0,0 +1,113 @@
+package vite_test
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/olivere/vite"
+)
+
+func TestValidateHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []error
+		line int
+	}{
+		{
+			name: "Valid",
+			html: "<!doctype html>\n<html>\n<head><meta charset=\"UTF-8\" /><title>a < b</title><script>if (a < b) {}</script></head>\n<body><ul><li>One<li>Two</ul><p>Text<div id=\"root\"></div><svg><path d=\"M0\"/></svg></body>\n</html>\n",
+		},
+		{
+			name: "UnclosedTag",
+			html: "<html><body>\n<div id=\"root\">\n<span>\n</div></body></html>",
+			want: []error{vite.ErrUnclosedTag},
+			line: 3,
+		},
+		{
+			name: "StrayEndTag",
+			html: "<div></div>\n</section>",
+			want: []error{vite.ErrStrayEndTag},
+			line: 2,
+		},
+		{
+			name: "DuplicateID",
+			html: "<html><body><div id=\"root\"></div>\n<div id=\"root\"></div></body></html>",
+			want: []error{vite.ErrDuplicateID},
+			line: 2,
+		},
+		{
+			name: "MisplacedScript",
+			html: "<html><head></head><body></body>\n<script type=\"module\" src=\"/main.js\"></script></html>",
+			want: []error{vite.ErrMisplacedScript},
+			line: 2,
+		},
+		{
+			name: "Fragment",
+			html: `<script type="module" src="/main.js"></script><link rel="stylesheet" href="/main.css">`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := vite.ValidateHTML(strings.NewReader(tt.html))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if !errors.Is(err, want) {
+					t.Errorf("expected %v, got %v", want, err)
+				}
+			}
+			var htmlErr *vite.HTMLError
+			if !errors.As(err, &htmlErr) {
+				t.Fatalf("expected an HTMLError, got %v", err)
+			}
+			if htmlErr.Line != tt.line {
+				t.Errorf("expected line %d, got %d", tt.line, htmlErr.Line)
+			}
+		})
+	}
+}
+
+func TestHandlerValidateHTML(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	h, err := vite.NewHandler(vite.Config{
+		FS:           fstest.MapFS{},
+		IsDev:        true,
+		ValidateHTML: true,
+		Logger:       logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.RegisterTemplate("/broken", `<html><body><div id="root"></div><div id="root"></body></html>`)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if strings.Contains(buf.String(), "invalid HTML") {
+		t.Fatalf("expected the fallback template to be valid, got:\n%s", buf.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	for _, want := range []string{`msg="Rendered page has invalid HTML"`, "url=/broken", "duplicate id", "unclosed tag <div>"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected log to contain %s, got:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package vite
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+// DefaultVitalsPath is the path web vitals are posted to if
+// Config.VitalsPath is empty.
+const DefaultVitalsPath = "/__vitals"
+
+// maxVitalsBodySize limits the size of a web vitals beacon.
+const maxVitalsBodySize = 64 << 10
+
+// WebVital is a Core Web Vitals measurement reported by a browser.
+type WebVital struct {
+	// Name is the name of the metric: "TTFB", "FCP", "LCP", "CLS", or "INP".
+	Name string `json:"name"`
+	// Value is the value of the metric, in milliseconds for all metrics
+	// except CLS, which is unitless.
+	Value float64 `json:"value"`
+	// ID identifies the page view the metric was measured in.
+	ID string `json:"id"`
+	// Page is the path of the page.
+	Page string `json:"page"`
+	// Version is the asset version the page was served with: the file of
+	// the entry point in production mode, e.g. "assets/main-4f2e1a.js",
+	// and "dev" in development mode.
+	Version string `json:"version"`
+}
+
+// VitalsRecorder records web vitals reported by browsers.
+type VitalsRecorder interface {
+	RecordWebVital(r *http.Request, v WebVital)
+}
+
+// VitalsRecorderFunc is an adapter to allow the use of ordinary functions
+// as [VitalsRecorder].
+type VitalsRecorderFunc func(r *http.Request, v WebVital)
+
+// RecordWebVital calls f(r, v).
+func (f VitalsRecorderFunc) RecordWebVital(r *http.Request, v WebVital) {
+	f(r, v)
+}
+
+// VitalsHandler returns a handler that accepts web vitals beacons as sent
+// by [VitalsScript] and passes each measurement to rec.
+func VitalsHandler(rec VitalsRecorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var vitals []WebVital
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxVitalsBodySize)).Decode(&vitals); err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		for _, v := range vitals {
+			if v.Name == "" {
+				continue
+			}
+			rec.RecordWebVital(r, v)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// VitalsScript returns an inline script that measures the Core Web Vitals
+// of the page with PerformanceObserver and posts them to url when the page
+// is hidden. The version is reported with every measurement. If nonce is
+// not empty, it is set as the nonce attribute of the script tag.
+func VitalsScript(url, version, nonce string) template.HTML {
+	var attrs string
+	if nonce != "" {
+		attrs = fmt.Sprintf(` nonce="%s"`, template.HTMLEscapeString(nonce))
+	}
+	return template.HTML(fmt.Sprintf(
+		`<script%s data-url="%s" data-version="%s">%s</script>`,
+		attrs,
+		template.HTMLEscapeString(url),
+		template.HTMLEscapeString(version),
+		vitalsJS,
+	))
+}
+
+const vitalsJS = `(function(){` +
+	`var s=document.currentScript,u=s.dataset.url,v=s.dataset.version,q=[],done=false,lcp=0,cls=0,inp=0,` +
+	`id=Date.now()+"-"+Math.random().toString(36).slice(2);` +
+	`function add(n,x){q.push({name:n,value:x,id:id,page:location.pathname,version:v})}` +
+	`function observe(t,f,o){try{new PerformanceObserver(function(l){l.getEntries().forEach(f)}).observe(Object.assign({type:t,buffered:true},o))}catch(e){}}` +
+	`var n=performance.getEntriesByType("navigation")[0];if(n)add("TTFB",n.responseStart);` +
+	`observe("paint",function(e){if(e.name==="first-contentful-paint")add("FCP",e.startTime)});` +
+	`observe("largest-contentful-paint",function(e){lcp=e.startTime});` +
+	`observe("layout-shift",function(e){if(!e.hadRecentInput)cls+=e.value});` +
+	`observe("event",function(e){if(e.interactionId&&e.duration>inp)inp=e.duration},{durationThreshold:40});` +
+	`addEventListener("visibilitychange",function(){` +
+	`if(document.visibilityState!=="hidden"||done)return;done=true;` +
+	`if(lcp)add("LCP",lcp);add("CLS",cls);if(inp)add("INP",inp);` +
+	`var b=JSON.stringify(q);q=[];` +
+	`if(!(navigator.sendBeacon&&navigator.sendBeacon(u,b)))fetch(u,{method:"POST",body:b,keepalive:true})` +
+	`})})();`
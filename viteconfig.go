@@ -0,0 +1,248 @@
+package vite
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ViteConfig holds the settings of a Vite config file that matter to the
+// Go side. See [ParseViteConfig].
+type ViteConfig struct {
+	// Root is the project root, relative to the config file ("root").
+	Root string
+	// Base is the public base path ("base"). It defaults to "/".
+	Base string
+	// OutDir is the output directory, relative to Root ("build.outDir").
+	// It defaults to "dist".
+	OutDir string
+	// AssetsDir is the directory of the assets, relative to OutDir
+	// ("build.assetsDir"). It defaults to "assets".
+	AssetsDir string
+	// Manifest is the path of the manifest, relative to OutDir
+	// ("build.manifest"). It is empty if no manifest is generated.
+	Manifest string
+	// Entry is the entry point, if it is a single file
+	// ("build.rollupOptions.input").
+	Entry string
+	// Host is the host of the dev server ("server.host"). It defaults to
+	// "localhost".
+	Host string
+	// Port is the port of the dev server ("server.port"). It defaults to
+	// 5173.
+	Port int
+}
+
+// ConfigFromViteConfig returns a configuration for the Vite config file at
+// the given path, e.g. "frontend/vite.config.ts", so that the Go side stays
+// in sync with the JavaScript side. FS is set to the output directory,
+// ViteURL to the URL of the dev server, and Base to the public base path.
+// For development mode, set FS to the project root instead, e.g. with
+// [Config.FromEnv].
+//
+// Parsing is best-effort, see [ParseViteConfig].
+func ConfigFromViteConfig(filename string) (Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Config{}, fmt.Errorf("vite: unable to read vite config: %w", err)
+	}
+	vc, err := ParseViteConfig(data)
+	if err != nil {
+		return Config{}, err
+	}
+
+	root := filepath.Join(filepath.Dir(filename), vc.Root)
+	return Config{
+		FS:           os.DirFS(filepath.Join(root, vc.OutDir)),
+		ViteEntry:    vc.Entry,
+		ViteURL:      "http://" + vc.Host + ":" + strconv.Itoa(vc.Port),
+		ViteManifest: vc.Manifest,
+		Base:         vc.Base,
+		AssetsPrefix: path.Join("/", vc.AssetsDir) + "/",
+	}, nil
+}
+
+// ParseViteConfig extracts settings from the source of a Vite config file,
+// i.e. vite.config.ts or vite.config.js. Parsing is best-effort: it only
+// picks up settings whose values are literals, ignores everything inside
+// arrays (e.g. plugin options), and uses the Vite defaults for everything
+// else. Settings computed at runtime are not detected.
+func ParseViteConfig(src []byte) (*ViteConfig, error) {
+	values, err := parseConfigLiterals(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	vc := &ViteConfig{
+		Base:      "/",
+		OutDir:    "dist",
+		AssetsDir: "assets",
+		Host:      "localhost",
+		Port:      5173,
+	}
+	if v, ok := values["root"]; ok {
+		vc.Root = v
+	}
+	if v, ok := values["base"]; ok {
+		vc.Base = v
+	}
+	if v, ok := values["build.outDir"]; ok {
+		vc.OutDir = v
+	}
+	if v, ok := values["build.assetsDir"]; ok {
+		vc.AssetsDir = v
+	}
+	switch v := values["build.manifest"]; v {
+	case "", "false":
+	case "true":
+		vc.Manifest = ".vite/manifest.json"
+	default:
+		vc.Manifest = v
+	}
+	if v, ok := values["build.rollupOptions.input"]; ok {
+		vc.Entry = strings.TrimPrefix(v, "/")
+	}
+	if v, ok := values["server.host"]; ok && v != "true" && v != "false" && v != "0.0.0.0" && v != "::" {
+		vc.Host = v
+	}
+	if v, ok := values["server.port"]; ok {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("vite: invalid server.port %q in vite config", v)
+		}
+		vc.Port = port
+	}
+	return vc, nil
+}
+
+// configToken is a token of JavaScript source.
+type configToken struct {
+	kind  byte // 'i' identifier, 's' string, 'n' number, or punctuation
+	value string
+}
+
+// parseConfigLiterals returns the literal values of the object properties
+// in a JavaScript source, keyed by their dotted path, e.g. "server.port".
+func parseConfigLiterals(src string) (map[string]string, error) {
+	tokens := tokenizeConfig(src)
+	values := make(map[string]string)
+
+	// The stack holds the key of each open object, "" for objects without
+	// a key (e.g. the argument of defineConfig), and "[" for arrays.
+	var stack []string
+	keyPath := func(key string) (string, bool) {
+		var parts []string
+		for _, k := range stack {
+			switch k {
+			case "[":
+				return "", false
+			case "":
+			default:
+				parts = append(parts, k)
+			}
+		}
+		return strings.Join(append(parts, key), "."), true
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case (tok.kind == 'i' || tok.kind == 's') && i+1 < len(tokens) && tokens[i+1].kind == ':':
+			i += 2
+			if i >= len(tokens) {
+				break
+			}
+			switch next := tokens[i]; next.kind {
+			case '{':
+				stack = append(stack, tok.value)
+			case '[':
+				stack = append(stack, "[")
+			case 's', 'n', 'i':
+				if next.kind == 'i' && next.value != "true" && next.value != "false" {
+					continue
+				}
+				if i+1 < len(tokens) && tokens[i+1].kind != ',' && tokens[i+1].kind != '}' {
+					continue
+				}
+				if p, ok := keyPath(tok.value); ok {
+					values[p] = next.value
+				}
+			}
+		case tok.kind == '{':
+			stack = append(stack, "")
+		case tok.kind == '[':
+			stack = append(stack, "[")
+		case tok.kind == '}' || tok.kind == ']':
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("vite: unbalanced %q in vite config", tok.kind)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("vite: unbalanced brackets in vite config")
+	}
+	return values, nil
+}
+
+// tokenizeConfig splits JavaScript source into tokens, skipping whitespace
+// and comments. Template literals are returned as strings.
+func tokenizeConfig(src string) []configToken {
+	var tokens []configToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			if j := strings.IndexByte(src[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(src)
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			if j := strings.Index(src[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(src)
+			}
+		case c == '\'' || c == '"' || c == '`':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+			}
+			tokens = append(tokens, configToken{kind: 's', value: sb.String()})
+			i = j + 1
+		case isIdentByte(c) && (c < '0' || c > '9'):
+			j := i
+			for j < len(src) && isIdentByte(src[j]) {
+				j++
+			}
+			tokens = append(tokens, configToken{kind: 'i', value: src[i:j]})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (isIdentByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, configToken{kind: 'n', value: src[i:j]})
+			i = j
+		default:
+			tokens = append(tokens, configToken{kind: c, value: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,100 @@
+package vite_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olivere/vite"
+)
+
+func TestParseViteConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want vite.ViteConfig
+	}{
+		{
+			name: "defaults",
+			src:  `export default {}`,
+			want: vite.ViteConfig{Base: "/", OutDir: "dist", AssetsDir: "assets", Host: "localhost", Port: 5173},
+		},
+		{
+			name: "example",
+			src: `import react from '@vitejs/plugin-react'
+import { defineConfig } from 'vite'
+
+// https://vitejs.dev/config/
+export default defineConfig({
+  plugins: [react({ base: "/ignored/" })],
+  base: '/app/',
+  server: {
+    host: "127.0.0.1",
+    port: 3000, // the dev server port
+  },
+  build: {
+    /* generates .vite/manifest.json in outDir */
+    manifest: true,
+    outDir: "../static",
+    emptyOutDir: false,
+    rollupOptions: {
+      // overwrite default .html entry
+      input: "/src/main.tsx",
+    },
+  },
+})`,
+			want: vite.ViteConfig{Base: "/app/", OutDir: "../static", AssetsDir: "assets", Manifest: ".vite/manifest.json", Entry: "src/main.tsx", Host: "127.0.0.1", Port: 3000},
+		},
+		{
+			name: "function",
+			src: `export default defineConfig(({ mode }) => {
+  return {
+    build: { manifest: "manifest.json", assetsDir: "static" },
+    server: { port: process.env.PORT || 4000 },
+  }
+})`,
+			want: vite.ViteConfig{Base: "/", OutDir: "dist", AssetsDir: "static", Manifest: "manifest.json", Host: "localhost", Port: 5173},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vc, err := vite.ParseViteConfig([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *vc != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, *vc)
+			}
+		})
+	}
+}
+
+func TestConfigFromViteConfig(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "vite.config.ts")
+	src := `export default defineConfig({ base: "/app/", server: { port: 3000 }, build: { manifest: true } })`
+	if err := os.WriteFile(filename, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := vite.ConfigFromViteConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "http://localhost:3000", config.ViteURL; want != have {
+		t.Errorf("expected ViteURL %q, got %q", want, have)
+	}
+	if want, have := "/app/", config.Base; want != have {
+		t.Errorf("expected Base %q, got %q", want, have)
+	}
+	if want, have := ".vite/manifest.json", config.ViteManifest; want != have {
+		t.Errorf("expected ViteManifest %q, got %q", want, have)
+	}
+	if want, have := "/assets/", config.AssetsPrefix; want != have {
+		t.Errorf("expected AssetsPrefix %q, got %q", want, have)
+	}
+	if config.FS == nil {
+		t.Error("expected FS to be set")
+	}
+}